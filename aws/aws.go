@@ -1,16 +1,88 @@
 package aws
 
 import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
-var (
-	sess *session.Session
-)
+// defaultRoleSessionName is the session name of the assumed roles if Config has none.
+const defaultRoleSessionName = "rosculus"
+
+// Config describes how a session reaches an AWS account and a region.
+// The zero value is the default credential chain and the default region.
+type Config struct {
+	// Region defaults to the region of the profile or the environment.
+	Region string
+	// Profile is the profile in the shared config and credentials files.
+	Profile string
+	// RoleARN is assumed with the credentials of the profile or the environment.
+	RoleARN string
+	// ExternalID is passed to AssumeRole if the trust policy of the role requires it.
+	ExternalID string
+	// RoleSessionName defaults to "rosculus".
+	RoleSessionName string
+	// WebIdentityTokenFile assumes RoleARN with the OIDC token in the file
+	// instead of the credentials of the profile or the environment.
+	WebIdentityTokenFile string
+}
+
+// NewSession returns a session built from the config.
+func NewSession(config *Config) (*session.Session, error) {
+	if config == nil {
+		config = &Config{}
+	}
+
+	opts := session.Options{
+		Config:            *aws.NewConfig(),
+		Profile:           config.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	}
+	if config.Region != "" {
+		opts.Config.Region = aws.String(config.Region)
+	}
+	base, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RoleARN == "" {
+		if config.WebIdentityTokenFile != "" {
+			return nil, errors.New("a role ARN is required to use a web identity token file")
+		}
+		return base, nil
+	}
+
+	sessionName := config.RoleSessionName
+	if sessionName == "" {
+		sessionName = defaultRoleSessionName
+	}
+
+	var creds *credentials.Credentials
+	if config.WebIdentityTokenFile != "" {
+		creds = stscreds.NewWebIdentityCredentials(base, config.RoleARN, sessionName, config.WebIdentityTokenFile)
+	} else {
+		creds = stscreds.NewCredentials(base, config.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = sessionName
+			if config.ExternalID != "" {
+				p.ExternalID = aws.String(config.ExternalID)
+			}
+		})
+	}
+
+	return base.Copy(aws.NewConfig().WithCredentials(creds)), nil
+}
 
-func Session() *session.Session {
-	if sess == nil {
-		sess = session.Must(session.NewSession())
+// AccountID returns the ID of the AWS account which the session belongs to.
+func AccountID(ctx context.Context, s *session.Session) (string, error) {
+	resp, err := sts.New(s).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
 	}
-	return sess
+	return aws.StringValue(resp.Account), nil
 }
@@ -2,33 +2,41 @@ package s3
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
+	"net/http"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
-	awspkg "github.com/munisystem/rosculus/aws"
 )
 
 var (
-	s3cli *s3.S3
+	// ErrNotExist is returned when the object does not exist.
+	ErrNotExist = errors.New("the object does not exist")
+	// ErrPreconditionFailed is returned when a conditional write finds the object changed by another writer.
+	ErrPreconditionFailed = errors.New("the object was changed by another writer")
 )
 
-func client() *s3.S3 {
-	if s3cli == nil {
-		s3cli = s3.New(awspkg.Session())
-	}
-	return s3cli
+// Client reads and writes objects with the credentials of its session.
+type Client struct {
+	s3 *s3.S3
 }
 
-func Download(bucket, key string) ([]byte, error) {
-	cli := client()
+// New returns a Client which uses the session.
+func New(sess *session.Session) *Client {
+	return &Client{s3: s3.New(sess)}
+}
 
+func (c *Client) Download(ctx context.Context, bucket, key string) ([]byte, error) {
 	params := &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	}
 
-	resp, err := cli.GetObject(params)
+	resp, err := c.s3.GetObjectWithContext(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -39,16 +47,101 @@ func Download(bucket, key string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func Upload(bucket, key string, body []byte) error {
-	cli := client()
+func (c *Client) Upload(ctx context.Context, bucket, key string, body []byte) error {
+	params := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}
+
+	if _, err := c.s3.PutObjectWithContext(ctx, params); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List returns the keys of the objects in the bucket which start with prefix.
+func (c *Client) List(ctx context.Context, bucket, prefix string) ([]string, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	keys := []string{}
+	err := c.s3.ListObjectsV2PagesWithContext(ctx, params, func(resp *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range resp.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DownloadWithETag returns the object and its ETag, or ErrNotExist if it does not exist.
+func (c *Client) DownloadWithETag(ctx context.Context, bucket, key string) ([]byte, string, error) {
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	resp, err := c.s3.GetObjectWithContext(ctx, params)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, "", ErrNotExist
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), aws.StringValue(resp.ETag), nil
+}
 
+// UploadIf writes the object only if its ETag is etag, or only if it does not exist if etag is empty,
+// and returns the new ETag. It returns ErrPreconditionFailed if the object is not the expected one.
+func (c *Client) UploadIf(ctx context.Context, bucket, key string, body []byte, etag string) (string, error) {
 	params := &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(body),
 	}
 
-	if _, err := cli.PutObject(params); err != nil {
+	req, resp := c.s3.PutObjectRequest(params)
+	req.SetContext(ctx)
+	// This version of the SDK does not know the conditional writes of S3, so the headers are set directly.
+	if etag == "" {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	} else {
+		req.HTTPRequest.Header.Set("If-Match", etag)
+	}
+	if err := req.Send(); err != nil {
+		// S3 answers 409 when a concurrent conditional write on the object is in progress.
+		if rerr, ok := err.(awserr.RequestFailure); ok && (rerr.StatusCode() == http.StatusPreconditionFailed || rerr.StatusCode() == http.StatusConflict) {
+			return "", ErrPreconditionFailed
+		}
+		return "", err
+	}
+
+	return aws.StringValue(resp.ETag), nil
+}
+
+// Delete deletes the object.
+func (c *Client) Delete(ctx context.Context, bucket, key string) error {
+	params := &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	if _, err := c.s3.DeleteObjectWithContext(ctx, params); err != nil {
 		return err
 	}
 
@@ -0,0 +1,46 @@
+package secretsmanager
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// Client reads and writes the secrets of Secrets Manager with the credentials of its session.
+type Client struct {
+	secretsmanager *secretsmanager.SecretsManager
+}
+
+// New returns a Client which uses the session.
+func New(sess *session.Session) *Client {
+	return &Client{secretsmanager: secretsmanager.New(sess)}
+}
+
+// GetSecretValue returns the current string value of the secret, which is the name or the ARN.
+func (c *Client) GetSecretValue(ctx context.Context, secretID string) (string, error) {
+	resp, err := c.secretsmanager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(resp.SecretString), nil
+}
+
+// PutSecretValue makes the value the current version of the secret, creating the secret if it does not exist.
+func (c *Client) PutSecretValue(ctx context.Context, secretID, value string) error {
+	_, err := c.secretsmanager.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretID),
+		SecretString: aws.String(value),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		_, err = c.secretsmanager.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+			Name:         aws.String(secretID),
+			SecretString: aws.String(value),
+		})
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package ssm
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Client reads and writes the parameters of Parameter Store with the credentials of its session.
+type Client struct {
+	ssm *ssm.SSM
+}
+
+// New returns a Client which uses the session.
+func New(sess *session.Session) *Client {
+	return &Client{ssm: ssm.New(sess)}
+}
+
+// GetParameter returns the value of the parameter, decrypting a SecureString.
+func (c *Client) GetParameter(ctx context.Context, name string) (string, error) {
+	resp, err := c.ssm.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(resp.Parameter.Value), nil
+}
+
+// PutParameter writes the value to the parameter as a SecureString, overwriting the current value.
+func (c *Client) PutParameter(ctx context.Context, name, value string) error {
+	_, err := c.ssm.PutParameterWithContext(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Overwrite: aws.Bool(true),
+	})
+	return err
+}
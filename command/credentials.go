@@ -0,0 +1,97 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/secret"
+)
+
+// defaultPasswordLength is the length of the generated passwords if the config has none.
+const defaultPasswordLength = 32
+
+// credentials are published to the sinks with the keys of the secrets which RDS manages in Secrets Manager,
+// so that the existing clients of those secrets can read them.
+type credentials struct {
+	Username             string `json:"username"`
+	Password             string `json:"password"`
+	Host                 string `json:"host"`
+	Port                 int64  `json:"port"`
+	DBName               string `json:"dbname"`
+	DBInstanceIdentifier string `json:"dbInstanceIdentifier,omitempty"`
+	DBClusterIdentifier  string `json:"dbClusterIdentifier,omitempty"`
+}
+
+// masterUserPassword returns the master user password of the new clone of the config,
+// which is random if the config generates one.
+func masterUserPassword(config *config.Config) (string, error) {
+	if !config.Credentials.Generate {
+		return config.DBMasterUserPassword, nil
+	}
+
+	length := config.Credentials.Length
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+	return secret.GeneratePassword(length)
+}
+
+// republishCredentials publishes the credentials of the clone which the record is pointed back at to the sinks,
+// which hold the ones of the clone rolled back from. The password of the clone was published only until the
+// next rotation overwrote it, so a new one is set with setPassword if the config generates the passwords.
+func republishCredentials(ctx context.Context, sess *session.Session, config *config.Config, dbIdentifier string, instance *database.DBInstance, setPassword func(ctx context.Context, password string) error) error {
+	if len(config.Credentials.Sinks) == 0 {
+		return nil
+	}
+
+	password, err := masterUserPassword(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate the master user password: %w", err)
+	}
+	if config.Credentials.Generate {
+		if err := setPassword(ctx, password); err != nil {
+			return err
+		}
+	}
+
+	c := *instance
+	c.Password = password
+	return publishCredentials(ctx, sess, config, dbIdentifier, &c)
+}
+
+// publishCredentials writes the credentials and the endpoint of the clone to every sink of the config.
+// sess is used for the sinks in AWS.
+func publishCredentials(ctx context.Context, sess *session.Session, config *config.Config, dbIdentifier string, instance *database.DBInstance) error {
+	c := &credentials{
+		Username: instance.User,
+		Password: instance.Password,
+		Host:     instance.URL,
+		Port:     instance.Port,
+		DBName:   instance.Database,
+	}
+	if config.IsDBInstance() {
+		c.DBInstanceIdentifier = dbIdentifier
+	} else {
+		c.DBClusterIdentifier = dbIdentifier
+	}
+	value, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range config.Credentials.Sinks {
+		sink, err := secret.NewSink(sess, s)
+		if err != nil {
+			return err
+		}
+		if err := sink.Publish(ctx, value); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
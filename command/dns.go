@@ -0,0 +1,131 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/dns/route53"
+)
+
+const (
+	defaultPropagationTimeout  = 10 * time.Minute
+	defaultPropagationInterval = 10 * time.Second
+)
+
+// recordValue returns the value of a record of recordType which points at the endpoint.
+// A and AAAA records get the address which the endpoint resolves to.
+func recordValue(recordType, endpoint string) (string, error) {
+	switch recordType {
+	case dns.TypeA, dns.TypeAAAA:
+		ips, err := net.LookupIP(endpoint)
+		if err != nil {
+			return "", err
+		}
+		for _, ip := range ips {
+			if (ip.To4() != nil) == (recordType == dns.TypeA) {
+				return ip.String(), nil
+			}
+		}
+		return "", fmt.Errorf("%s has no %s address", endpoint, recordType)
+	default:
+		return endpoint, nil
+	}
+}
+
+// pointsAt reports whether the record points at the endpoint.
+func pointsAt(record *dns.Record, endpoint string) bool {
+	if record == nil || endpoint == "" {
+		return false
+	}
+
+	switch record.Type {
+	case dns.TypeA, dns.TypeAAAA:
+		ips, err := net.LookupIP(endpoint)
+		if err != nil {
+			return false
+		}
+		for _, ip := range ips {
+			if ip.Equal(net.ParseIP(record.Value)) {
+				return true
+			}
+		}
+		return false
+	default:
+		return strings.TrimSuffix(record.Value, ".") == endpoint
+	}
+}
+
+// checkPropagation returns a *config.ValidationError if the propagation of the record would be polled on
+// the public nameservers of a private Route 53 hosted zone, which never answer it, so that the rotation
+// fails before it changes anything instead of after the record is switched. sess manages Route 53.
+func checkPropagation(ctx context.Context, cfg *config.Config, sess *session.Session) error {
+	if cfg.Propagation.Skip || cfg.Propagation.Resolver != "" || cfg.DNSProvider() != config.DNSProviderRoute53 {
+		return nil
+	}
+
+	private, err := route53.PrivateZone(ctx, sess, cfg.Route53.HostedZoneID)
+	if err != nil {
+		return &dns.Error{Provider: "Route 53", Op: "get", Domain: cfg.Route53.Domain, Err: err}
+	}
+	if private {
+		return &config.ValidationError{Errors: []*config.FieldError{{
+			Field:   "Propagation.Resolver",
+			Message: fmt.Sprintf("is required unless Propagation.Skip is set, as hosted zone %s is private", cfg.Route53.HostedZoneID),
+		}}}
+	}
+	return nil
+}
+
+// waitForPropagation waits until the nameservers answer the new value of the record,
+// and then for the TTL of the old record, so that no client resolves to the previous clone any more.
+func waitForPropagation(ctx context.Context, config *config.Config, domain string, record, old *dns.Record) error {
+	if config.Propagation.Skip {
+		return nil
+	}
+
+	p := &dns.Propagation{
+		Timeout:  config.Propagation.Timeout,
+		Interval: config.Propagation.Interval,
+	}
+	if p.Timeout == 0 {
+		p.Timeout = defaultPropagationTimeout
+	}
+	if p.Interval == 0 {
+		p.Interval = defaultPropagationInterval
+	}
+
+	if config.Propagation.Resolver != "" {
+		p.Nameservers = []string{config.Propagation.Resolver}
+		p.Recursive = true
+	} else {
+		nameservers, err := dns.AuthoritativeNameservers(ctx, domain)
+		if err != nil {
+			return err
+		}
+		p.Nameservers = nameservers
+	}
+
+	log.Printf("wait until %s resolves to %s on %s\n", record.Name, record.Value, strings.Join(p.Nameservers, ", "))
+	if err := p.Wait(ctx, domain, record); err != nil {
+		return err
+	}
+
+	if old != nil && old.Value != record.Value && old.TTL > 0 {
+		ttl := time.Duration(old.TTL) * time.Second
+		log.Printf("wait %s until the old record expires from caches\n", ttl)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ttl):
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/lib/postgres"
+	"github.com/munisystem/rosculus/lock"
+)
+
+// The exit codes of the commands. Only the failures with exitLocked and exitTemporary
+// may succeed when the command is run again as it is.
+const (
+	exitOK = 0
+	// exitError is a failure which is none of the below.
+	exitError = 1
+	// exitUsage is a wrong flag or argument.
+	exitUsage = 2
+	// exitConfig is a config which cannot be loaded, is invalid or refers to secrets which cannot be resolved.
+	exitConfig = 3
+	// exitLocked is another run on the config holding the lock.
+	exitLocked = 4
+	// exitTemporary is a failure which a later run may not have, such as throttling by AWS,
+	// a resource which is busy or a record which has not propagated yet.
+	exitTemporary = 5
+	// exitAWS is a failure of RDS or another service of AWS.
+	exitAWS = 6
+	// exitDNS is a failure of the DNS provider.
+	exitDNS = 7
+	// exitDatabase is a failure to connect the clone or to run the queries on it.
+	exitDatabase = 8
+	// exitConflict is a resource which is not in the state which the command expects,
+	// such as a DNS record pointing at none of the clones.
+	exitConflict = 9
+	// exitInterrupted is a run which was stopped by SIGINT, SIGTERM, -timeout or the loss of its lock.
+	// An interrupted rotation is continued by rotate -resume.
+	exitInterrupted = 10
+)
+
+// conflictError is returned when a resource is not in the state which the command expects.
+type conflictError struct {
+	message string
+}
+
+func (e *conflictError) Error() string {
+	return e.message
+}
+
+func conflictf(format string, args ...interface{}) error {
+	return &conflictError{message: fmt.Sprintf(format, args...)}
+}
+
+// interruptedError is the failure of a run whose context was canceled, or whose lock was lost.
+type interruptedError struct {
+	err   error
+	cause error
+}
+
+func (e *interruptedError) Error() string {
+	switch e.cause {
+	case context.DeadlineExceeded:
+		return "timed out: " + e.err.Error()
+	case lock.ErrLost, lock.ErrExpired:
+		return "lost the lock (" + e.cause.Error() + "): " + e.err.Error()
+	default:
+		return "interrupted: " + e.err.Error()
+	}
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+// interrupted returns err as an *interruptedError if ctx has been canceled, as the failures
+// of the canceled calls do not always wrap the error of ctx.
+func interrupted(ctx context.Context, err error) error {
+	var already *interruptedError
+	if err == nil || ctx.Err() == nil || errors.As(err, &already) {
+		return err
+	}
+	return &interruptedError{err: err, cause: ctx.Err()}
+}
+
+// exitCode returns the exit code of the command which failed with err.
+func exitCode(err error) int {
+	var (
+		held        *lock.HeldError
+		interrupt   *interruptedError
+		validation  *config.ValidationError
+		load        *config.LoadError
+		secret      *config.SecretError
+		temporary   interface{ Temporary() bool }
+		rdsErr      *rds.Error
+		aerr        awserr.Error
+		dnsErr      *dns.Error
+		propagation *dns.PropagationError
+		connection  *postgres.ConnectionError
+		query       *postgres.QueryError
+		conflict    *conflictError
+	)
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &interrupt), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return exitInterrupted
+	case errors.As(err, &held):
+		return exitLocked
+	case errors.As(err, &validation), errors.As(err, &load), errors.As(err, &secret):
+		return exitConfig
+	case errors.As(err, &temporary) && temporary.Temporary():
+		return exitTemporary
+	case errors.As(err, &aerr) && (request.IsErrorThrottle(aerr) || request.IsErrorRetryable(aerr)):
+		return exitTemporary
+	case errors.As(err, &rdsErr), errors.As(err, &aerr):
+		return exitAWS
+	case errors.As(err, &dnsErr), errors.As(err, &propagation):
+		return exitDNS
+	case errors.As(err, &connection), errors.As(err, &query):
+		return exitDatabase
+	case errors.As(err, &conflict):
+		return exitConflict
+	default:
+		return exitError
+	}
+}
+
+// fail reports the error through Ui and returns the exit code of it.
+func (m *Meta) fail(err error) int {
+	m.Ui.Error(err.Error())
+	return exitCode(err)
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/lib/postgres"
+	"github.com/munisystem/rosculus/lock"
+)
+
+func TestExitCode(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("failed"), exitError},
+		{fmt.Errorf("failed to lock config staging: %w", &lock.HeldError{Lease: &lock.Lease{Key: "staging", ExpiresAt: time.Now()}}), exitLocked},
+		{fmt.Errorf("config staging is invalid: %w", &config.ValidationError{}), exitConfig},
+		{&config.LoadError{Path: "staging.yml", Err: errors.New("not found")}, exitConfig},
+		{&rds.Error{Op: "restore", Identifier: "db-1", Err: rds.ErrNotReady}, exitTemporary},
+		{&rds.Error{Op: "restore", Identifier: "db-1", Err: awserr.New("Throttling", "Rate exceeded", nil)}, exitTemporary},
+		{&rds.Error{Op: "restore", Identifier: "db-1", Err: awserr.New("DBSnapshotNotFound", "not found", nil)}, exitAWS},
+		{&dns.Error{Provider: "Route 53", Op: "update", Err: errors.New("denied")}, exitDNS},
+		{&dns.PropagationError{FQDN: "db.example.com.", Type: "CNAME", Timeout: time.Minute}, exitTemporary},
+		{&postgres.ConnectionError{Err: errors.New("refused")}, exitDatabase},
+		{&postgres.QueryError{Index: 0, Query: "DELETE FROM users", Err: errors.New("syntax error")}, exitDatabase},
+		{conflictf("there is no rotation of config %s to resume", "staging"), exitConflict},
+		{interrupted(canceled, &rds.Error{Op: "restore", Identifier: "db-1", Err: awserr.New("RequestCanceled", "request context canceled", nil)}), exitInterrupted},
+		{fmt.Errorf("failed to start step restore: %w", context.DeadlineExceeded), exitInterrupted},
+		{&interruptedError{err: errors.New("failed to update DNS record db"), cause: lock.ErrLost}, exitInterrupted},
+	}
+
+	for _, tc := range cases {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("%v: got %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
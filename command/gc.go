@@ -0,0 +1,236 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/database/rds"
+)
+
+type GCCommand struct {
+	Meta
+}
+
+func (c *GCCommand) Run(args []string) int {
+	var (
+		force              bool
+		configLocationFlag string
+		lockTimeout        time.Duration
+	)
+
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	flags.BoolVar(&force, "force", false, "")
+	flags.StringVar(&configLocationFlag, "config", "", "")
+	flags.DurationVar(&lockTimeout, "lock-timeout", 0, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	location, name, err := configLocation(configLocationFlag, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitUsage
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	if err := c.gc(ctx, location, name, force, lockTimeout); err != nil {
+		return c.fail(interrupted(ctx, err))
+	}
+	return exitOK
+}
+
+func (c *GCCommand) gc(ctx context.Context, location, name string, force bool, lockTimeout time.Duration) error {
+	now := time.Now()
+	config, sess, err := c.prepareConfig(ctx, location, name, now)
+	if err != nil {
+		return err
+	}
+
+	collect := func(ctx context.Context) error {
+		return c.collect(ctx, config, sess, name, force, now)
+	}
+	// Listing the orphans changes nothing and needs no lock.
+	if !force {
+		return collect(ctx)
+	}
+	return withLock(ctx, config, name, lockTimeout, collect)
+}
+
+// collect lists the orphaned clones of the config named name, and deletes them if force is true.
+func (c *GCCommand) collect(ctx context.Context, config *config.Config, sess *session.Session, name string, force bool, now time.Time) error {
+	rdsClient := rds.New(sess)
+
+	kind := databaseKind(config)
+	record := config.DNSRecord()
+
+	current, err := c.dnsClient(config, sess).GetRecord(ctx, record.Domain, record.Name, record.Type)
+	if err != nil {
+		return fmt.Errorf("failed to look up DNS record %s: %w", record.Name, err)
+	}
+
+	generations, err := listGenerations(ctx, rdsClient, config)
+	if err != nil {
+		return fmt.Errorf("failed to list the %ss: %w", kind, err)
+	}
+
+	var dbIdentifier string
+	for _, generation := range generations {
+		instance, err := describeGeneration(ctx, rdsClient, config, generation.Identifier)
+		if err != nil {
+			return fmt.Errorf("failed to get informations of %s %s: %w", kind, generation.Identifier, err)
+		}
+		if instance != nil && pointsAt(current, instance.URL) {
+			dbIdentifier = generation.Identifier
+			break
+		}
+	}
+	// Without the current clone every clone would look like an orphan,
+	// so refuse to guess rather than deleting the database in use.
+	if dbIdentifier == "" {
+		return conflictf("DNS record %s.%s does not point at any %s of config %s", record.Name, record.Domain, kind, name)
+	}
+
+	// The clones which running rotations are creating are newer than the current one, but not orphans.
+	// The ones of failed rotations do not count towards the retention.
+	running, failed := map[string]bool{}, map[string]bool{}
+	store, err := stateStore(config)
+	if err != nil {
+		return fmt.Errorf("failed to open the state of config %s: %w", name, err)
+	}
+	if store != nil {
+		rotations, err := store.List(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to read the rotations of config %s: %w", name, err)
+		}
+		running = runningRotations(rotations, now)
+		failed = failedGenerations(rotations)
+	}
+
+	orphans := []string{}
+	for _, identifier := range orphanGenerations(config, generations, dbIdentifier, failed, now) {
+		if running[identifier] {
+			c.Ui.Output(fmt.Sprintf("skipped %s %s, which a running rotation is creating", kind, identifier))
+			continue
+		}
+		orphans = append(orphans, identifier)
+	}
+
+	var members []string
+	if config.IsDBCluster() {
+		if members, err = orphanClusterInstances(ctx, rdsClient, config, generations); err != nil {
+			return fmt.Errorf("failed to list the RDS Instances of the Aurora Clusters: %w", err)
+		}
+	}
+
+	if len(orphans) == 0 && len(members) == 0 {
+		c.Ui.Output(fmt.Sprintf("no orphaned clone of config %s", name))
+		return nil
+	}
+
+	for _, identifier := range orphans {
+		c.Ui.Output(fmt.Sprintf("orphaned %s %s", kind, identifier))
+	}
+	for _, identifier := range members {
+		c.Ui.Output(fmt.Sprintf("orphaned RDS Instance %s", identifier))
+	}
+
+	if !force {
+		c.Ui.Output("run with -force to delete them")
+		return nil
+	}
+
+	for _, identifier := range orphans {
+		if err := deleteGeneration(ctx, rdsClient, config, identifier); err != nil {
+			return fmt.Errorf("failed to delete %s %s: %w", kind, identifier, err)
+		}
+		log.Printf("deleted %s %s\n", kind, identifier)
+	}
+	for _, identifier := range members {
+		if err := rdsClient.DeleteDBInstance(ctx, identifier); err != nil {
+			return fmt.Errorf("failed to delete RDS Instance %s: %w", identifier, err)
+		}
+		log.Printf("deleted RDS Instance %s\n", identifier)
+	}
+
+	return nil
+}
+
+// orphanGenerations returns the generations which neither the DNS record points at
+// nor the retention keeps, including the ones newer than current left by failed rotations.
+// generations are oldest first.
+func orphanGenerations(config *config.Config, generations []*rds.DBResource, current string, failed map[string]bool, now time.Time) []string {
+	orphans := expiredGenerations(config, generations, current, failed, now)
+	newer := false
+	for _, generation := range generations {
+		if newer {
+			orphans = append(orphans, generation.Identifier)
+		}
+		newer = newer || generation.Identifier == current
+	}
+	return orphans
+}
+
+// orphanClusterInstances returns the RDS Instances which were added to an Aurora Cluster
+// of the config but whose Aurora Cluster no longer exists.
+func orphanClusterInstances(ctx context.Context, rdsClient *rds.Client, config *config.Config, generations []*rds.DBResource) ([]string, error) {
+	instances, err := rdsClient.ListDBInstances(ctx, "", map[string]string{managedTagKey: managedTagValue})
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make(map[string]bool, len(generations))
+	for _, generation := range generations {
+		clusters[generation.Identifier] = true
+	}
+
+	orphans := []string{}
+	for _, instance := range instances {
+		// The RDS Instance has the tags of its Aurora Cluster.
+		cluster := strings.TrimSuffix(instance.Identifier, database.ClusterInstanceSuffix)
+		if cluster == instance.Identifier || !isGeneration(config, &rds.DBResource{Identifier: cluster, Tags: instance.Tags}) {
+			continue
+		}
+		if !clusters[cluster] {
+			orphans = append(orphans, instance.Identifier)
+		}
+	}
+
+	return orphans, nil
+}
+
+func (c *GCCommand) Synopsis() string {
+	return "Find and delete the clones which nothing points at"
+}
+
+func (c *GCCommand) Help() string {
+	helpText := `
+Usage: rosculus gc [options] [NAME]
+
+  List the clones of the config NAME which the DNS record does not point at
+  and the retention does not keep, such as the ones left by failed rotations.
+  With -force, it waits for the lock of the config like rotate, so it never
+  deletes the clone which a rotation in progress is creating.
+
+Options:
+
+  -config=LOCATION    Read the config from LOCATION instead of NAME.yml in the
+                      S3 bucket in AWS_S3_BUCKET_NAME. See rosculus rotate -h.
+
+  -force              Delete the orphaned clones instead of only listing them.
+
+  -lock-timeout=0s    Wait up to the duration for another run on the config to
+                      release the lock instead of failing at once. Only taken
+                      with -force.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+)
+
+func TestGCCommand_implement(t *testing.T) {
+	var _ cli.Command = &GCCommand{}
+}
+
+func TestOrphanGenerations(t *testing.T) {
+	now := time.Date(2017, 1, 4, 3, 0, 0, 0, time.UTC)
+	generations := []*rds.DBResource{
+		{Identifier: "db-20170101", CreateTime: now.Add(-72 * time.Hour)},
+		{Identifier: "db-20170102", CreateTime: now.Add(-48 * time.Hour)},
+		{Identifier: "db-20170103", CreateTime: now.Add(-24 * time.Hour)},
+		{Identifier: "db-20170104", CreateTime: now},
+	}
+
+	c := &config.Config{Retention: config.Retention{KeepGenerations: 2}}
+	want := []string{"db-20170101", "db-20170104"}
+	if got := orphanGenerations(c, generations, "db-20170103", nil, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,227 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/database/rds"
+)
+
+// Every clone which rosculus creates is tagged with managedTagKey and managedTagValue,
+// so that cleanup never touches databases which only happen to match the naming pattern.
+// baseTagKey and sequenceTagKey tell the config and the generation of the clone,
+// which the previous generations are found by regardless of the naming template.
+const (
+	managedTagKey   = "managed-by"
+	managedTagValue = "rosculus"
+	baseTagKey      = "rosculus-base"
+	sequenceTagKey  = "rosculus-generation"
+)
+
+// cloneTags returns the tags of the clone of the generation sequence of the config.
+func cloneTags(config *config.Config, sequence int) map[string]string {
+	tags := make(map[string]string, len(config.DBInstanceTags)+3)
+	for key, value := range config.DBInstanceTags {
+		tags[key] = value
+	}
+	tags[managedTagKey] = managedTagValue
+	tags[baseTagKey] = baseIdentifier(config)
+	tags[sequenceTagKey] = strconv.Itoa(sequence)
+	return tags
+}
+
+// baseIdentifier returns the identifier which the clones of the config are named after.
+func baseIdentifier(config *config.Config) string {
+	if config.IsDBInstance() {
+		return config.DBInstanceIdentifier
+	}
+	return config.DBClusterIdentifier
+}
+
+// databaseKind returns the human readable kind of the clones of the config.
+func databaseKind(config *config.Config) string {
+	if config.IsDBInstance() {
+		return "RDS Instance"
+	}
+	return "Aurora Cluster"
+}
+
+// legacyGenerationPattern matches the identifiers of the clones which were created
+// before the clones were tagged with baseTagKey, "<base>-20060102".
+func legacyGenerationPattern(config *config.Config) *regexp.Regexp {
+	return regexp.MustCompile("^" + regexp.QuoteMeta(baseIdentifier(config)) + `-\d{8}$`)
+}
+
+// isGeneration reports whether the resource managed by rosculus is a clone of the config.
+func isGeneration(config *config.Config, resource *rds.DBResource) bool {
+	if base, ok := resource.Tags[baseTagKey]; ok {
+		return base == baseIdentifier(config)
+	}
+	return legacyGenerationPattern(config).MatchString(resource.Identifier)
+}
+
+// generationSequence returns the generation of the clone, which is 0 for the legacy clones.
+func generationSequence(resource *rds.DBResource) int {
+	sequence, _ := strconv.Atoi(resource.Tags[sequenceTagKey])
+	return sequence
+}
+
+// nextGeneration returns the identifier and the sequence of the generation which a rotation at now creates.
+// Every rotation creates a new clone: if the naming template makes the identifier of an existing generation,
+// such as the second rotation of a day with the daily layout, the sequence is appended to it.
+// The base of the identifier is shortened to fit in the length limit of RDS.
+func nextGeneration(config *config.Config, generations []*rds.DBResource, now time.Time) (string, int, error) {
+	sequence := 1
+	taken := make(map[string]bool, len(generations))
+	for _, generation := range generations {
+		if s := generationSequence(generation); s >= sequence {
+			sequence = s + 1
+		}
+		taken[generation.Identifier] = true
+	}
+
+	name := func(base string) (string, error) {
+		identifier, err := config.Naming.Identifier(base, now, sequence)
+		if err != nil {
+			return "", err
+		}
+		if taken[identifier] {
+			identifier = fmt.Sprintf("%s-%d", identifier, sequence)
+		}
+		return identifier, nil
+	}
+
+	maxLength := database.MaxIdentifierLength
+	if config.IsDBCluster() {
+		maxLength -= len(database.ClusterInstanceSuffix)
+	}
+
+	base := baseIdentifier(config)
+	identifier, err := name(base)
+	if err != nil {
+		return "", 0, err
+	}
+	// The shortened identifier can be taken, and then gets longer again.
+	for over := len(identifier) - maxLength; over > 0 && over < len(base); over = len(identifier) - maxLength {
+		base = strings.TrimRight(base[:len(base)-over], "-")
+		if identifier, err = name(base); err != nil {
+			return "", 0, err
+		}
+	}
+	if len(identifier) > maxLength {
+		return "", 0, fmt.Errorf("%s is longer than %d characters", identifier, maxLength)
+	}
+
+	return identifier, sequence, nil
+}
+
+// listGenerations returns the clones of the config which rosculus manages, oldest first.
+func listGenerations(ctx context.Context, rdsClient *rds.Client, config *config.Config) ([]*rds.DBResource, error) {
+	var (
+		resources []*rds.DBResource
+		err       error
+	)
+
+	managed := map[string]string{managedTagKey: managedTagValue}
+	if config.IsDBInstance() {
+		resources, err = rdsClient.ListDBInstances(ctx, "", managed)
+	} else {
+		resources, err = rdsClient.ListDBClusters(ctx, "", managed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sortGenerations(config, resources), nil
+}
+
+// sortGenerations returns the clones of the config in resources, oldest first.
+// They are ordered by their generation, and then by their creation time.
+func sortGenerations(config *config.Config, resources []*rds.DBResource) []*rds.DBResource {
+	generations := []*rds.DBResource{}
+	for _, resource := range resources {
+		if isGeneration(config, resource) {
+			generations = append(generations, resource)
+		}
+	}
+	sort.SliceStable(generations, func(i, j int) bool {
+		si, sj := generationSequence(generations[i]), generationSequence(generations[j])
+		if si != sj {
+			return si < sj
+		}
+		if !generations[i].CreateTime.Equal(generations[j].CreateTime) {
+			return generations[i].CreateTime.Before(generations[j].CreateTime)
+		}
+		return generations[i].Identifier < generations[j].Identifier
+	})
+
+	return generations
+}
+
+// describeGeneration returns the connection information of the clone, or nil if it does not exist.
+func describeGeneration(ctx context.Context, rdsClient *rds.Client, config *config.Config, identifier string) (*database.DBInstance, error) {
+	if config.IsDBInstance() {
+		return rdsClient.DescribeDBInstance(ctx, identifier)
+	}
+	return rdsClient.DescribeDBCluster(ctx, identifier)
+}
+
+// setGenerationPassword sets the master user password of the clone.
+func setGenerationPassword(ctx context.Context, rdsClient *rds.Client, config *config.Config, identifier, password string) error {
+	if config.IsDBInstance() {
+		return rdsClient.ModifyDBInstancePassword(ctx, identifier, password)
+	}
+	return rdsClient.ModifyDBClusterPassword(ctx, identifier, password)
+}
+
+// deleteGeneration deletes the clone.
+func deleteGeneration(ctx context.Context, rdsClient *rds.Client, config *config.Config, identifier string) error {
+	if config.IsDBInstance() {
+		return rdsClient.DeleteDBInstance(ctx, identifier)
+	}
+	return rdsClient.DeleteDBCluster(ctx, identifier)
+}
+
+// expiredGenerations returns the generations which are older than current and
+// fall outside of the retention of the config at now. generations are oldest first,
+// and every one of them is older than current if current is not among them.
+// The failed generations never count towards the retention, and are expired.
+func expiredGenerations(config *config.Config, generations []*rds.DBResource, current string, failed map[string]bool, now time.Time) []string {
+	keep := config.Retention.KeepGenerations
+	if keep < 1 {
+		keep = 1
+	}
+	maxAge := config.Retention.MaxAge
+
+	older := generations
+	for i, generation := range generations {
+		if generation.Identifier == current {
+			older = generations[:i]
+			break
+		}
+	}
+
+	expired := []string{}
+	for i, generation := range older {
+		// The current generation counts towards KeepGenerations, and so do the newer ones which did not fail.
+		newer := 1
+		for _, g := range older[i+1:] {
+			if !failed[g.Identifier] {
+				newer++
+			}
+		}
+		tooOld := maxAge > 0 && !generation.CreateTime.IsZero() && now.Sub(generation.CreateTime) > maxAge
+		if failed[generation.Identifier] || newer > keep-1 || tooOld {
+			expired = append(expired, generation.Identifier)
+		}
+	}
+
+	return expired
+}
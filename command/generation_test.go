@@ -0,0 +1,130 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+)
+
+func TestExpiredGenerations(t *testing.T) {
+	now := time.Date(2017, 1, 4, 3, 0, 0, 0, time.UTC)
+	generations := []*rds.DBResource{
+		{Identifier: "db-20170101", CreateTime: now.Add(-72 * time.Hour)},
+		{Identifier: "db-20170102", CreateTime: now.Add(-48 * time.Hour)},
+		{Identifier: "db-20170103", CreateTime: now.Add(-24 * time.Hour)},
+		{Identifier: "db-20170104", CreateTime: now},
+	}
+
+	failed := map[string]bool{"db-20170102": true, "db-20170103": true}
+
+	cases := []struct {
+		retention config.Retention
+		current   string
+		failed    map[string]bool
+		want      []string
+	}{
+		{config.Retention{}, "db-20170104", nil, []string{"db-20170101", "db-20170102", "db-20170103"}},
+		{config.Retention{KeepGenerations: 1}, "db-20170104", nil, []string{"db-20170101", "db-20170102", "db-20170103"}},
+		{config.Retention{KeepGenerations: 2}, "db-20170104", nil, []string{"db-20170101", "db-20170102"}},
+		{config.Retention{KeepGenerations: 5}, "db-20170104", nil, []string{}},
+		{config.Retention{KeepGenerations: 2}, "db-20170103", nil, []string{"db-20170101"}},
+		{config.Retention{KeepGenerations: 5, MaxAge: 50 * time.Hour}, "db-20170104", nil, []string{"db-20170101"}},
+		{config.Retention{KeepGenerations: 2, MaxAge: 12 * time.Hour}, "db-20170104", nil, []string{"db-20170101", "db-20170102", "db-20170103"}},
+		// The failed generations are expired, and the newest one which succeeded is kept instead.
+		{config.Retention{KeepGenerations: 2}, "db-20170104", failed, []string{"db-20170102", "db-20170103"}},
+		{config.Retention{KeepGenerations: 5}, "db-20170104", failed, []string{"db-20170102", "db-20170103"}},
+	}
+
+	for _, tc := range cases {
+		c := &config.Config{Retention: tc.retention}
+		if got := expiredGenerations(c, generations, tc.current, tc.failed, now); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("retention %+v, current %s: got %v, want %v", tc.retention, tc.current, got, tc.want)
+		}
+	}
+}
+
+func TestSortGenerations(t *testing.T) {
+	now := time.Date(2017, 1, 4, 3, 0, 0, 0, time.UTC)
+	managed := func(base, sequence string) map[string]string {
+		return map[string]string{managedTagKey: managedTagValue, baseTagKey: base, sequenceTagKey: sequence}
+	}
+	resources := []*rds.DBResource{
+		{Identifier: "db-20170104-2", CreateTime: now, Tags: managed("db", "12")},
+		{Identifier: "db-20170104-1", CreateTime: now.Add(-time.Hour), Tags: managed("db", "11")},
+		{Identifier: "db-20170102", CreateTime: now.Add(-48 * time.Hour), Tags: map[string]string{managedTagKey: managedTagValue}},
+		{Identifier: "db-20170103", CreateTime: now.Add(-24 * time.Hour), Tags: managed("db", "10")},
+		{Identifier: "db-20170101", CreateTime: now.Add(-72 * time.Hour), Tags: map[string]string{managedTagKey: managedTagValue}},
+		{Identifier: "db-other-20170104", CreateTime: now, Tags: managed("db-other", "3")},
+		{Identifier: "db-manual", CreateTime: now, Tags: map[string]string{managedTagKey: managedTagValue}},
+	}
+
+	c := &config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db"}
+	generations := sortGenerations(c, resources)
+	got := make([]string, len(generations))
+	for i, generation := range generations {
+		got[i] = generation.Identifier
+	}
+	want := []string{"db-20170101", "db-20170102", "db-20170103", "db-20170104-1", "db-20170104-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	identifier, sequence, err := nextGeneration(c, generations, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identifier != "db-20170104" || sequence != 13 {
+		t.Errorf("got %s of generation %d, want db-20170104 of generation 13", identifier, sequence)
+	}
+
+	c.Naming = config.Naming{Template: "{{ .Base }}-{{ .Timestamp }}-{{ .Sequence }}", Layout: "200601021504", TimeZone: "Asia/Tokyo"}
+	if identifier, _, err = nextGeneration(c, generations, now); err != nil {
+		t.Fatal(err)
+	}
+	if identifier != "db-201701041200-13" {
+		t.Errorf("got %s, want db-201701041200-13", identifier)
+	}
+}
+
+func TestNextGeneration(t *testing.T) {
+	now := time.Date(2017, 1, 4, 3, 0, 0, 0, time.Local)
+	generation := func(identifier, sequence string) *rds.DBResource {
+		return &rds.DBResource{Identifier: identifier, Tags: map[string]string{sequenceTagKey: sequence}}
+	}
+
+	c := &config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db"}
+	cases := []struct {
+		generations  []*rds.DBResource
+		want         string
+		wantSequence int
+	}{
+		{nil, "db-20170104", 1},
+		{[]*rds.DBResource{generation("db-20170103", "1")}, "db-20170104", 2},
+		// The second rotation of the day gets a clone of its own.
+		{[]*rds.DBResource{generation("db-20170103", "1"), generation("db-20170104", "2")}, "db-20170104-3", 3},
+	}
+	for _, tc := range cases {
+		identifier, sequence, err := nextGeneration(c, tc.generations, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identifier != tc.want || sequence != tc.wantSequence {
+			t.Errorf("got %s of generation %d, want %s of generation %d", identifier, sequence, tc.want, tc.wantSequence)
+		}
+	}
+
+	// Long bases are shortened to fit in the limit of RDS, and the RDS Instance of an Aurora Cluster.
+	base := strings.Repeat("a", 60)
+	c = &config.Config{SourceDBClusterIdentifier: "source", DBClusterIdentifier: base}
+	identifier, _, err := nextGeneration(c, []*rds.DBResource{generation(base[:50]+"-20170104", "7")}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := base[:48] + "-20170104"; identifier != want {
+		t.Errorf("got %s, want %s", identifier, want)
+	}
+}
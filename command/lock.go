@@ -0,0 +1,86 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/aws/s3"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/lock"
+)
+
+// defaultLockTTL is the lease of the lock unless the config sets Lock.TTL.
+const defaultLockTTL = 5 * time.Minute
+
+// withLock runs fn while holding the lock of the config named name, waiting up to timeout while another
+// run holds it. The context of fn is canceled once the lock is lost, as another run may take it over.
+func withLock(ctx context.Context, cfg *config.Config, name string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	held, err := acquireLock(ctx, cfg, name, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock config %s: %w", name, err)
+	}
+	defer releaseLock(held)
+	return lockLost(held, fn(held.Context()))
+}
+
+// acquireLock takes the lock of the config named name, waiting up to timeout while another run holds it.
+func acquireLock(ctx context.Context, cfg *config.Config, name string, timeout time.Duration) (*lock.Lock, error) {
+	backend, err := lockBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ttl := cfg.Lock.TTL
+	if ttl == 0 {
+		ttl = defaultLockTTL
+	}
+	return lock.Acquire(ctx, backend, name, ttl, timeout)
+}
+
+// lockBackend returns the backend of the lock described by the config.
+// S3 and DynamoDB are accessed with bucketSession.
+func lockBackend(cfg *config.Config) (lock.Backend, error) {
+	location := cfg.Lock.Location
+	if location == "" {
+		return lock.NewFileBackend(filepath.Join(os.TempDir(), "rosculus")), nil
+	}
+	if !strings.Contains(location, "://") {
+		return lock.NewFileBackend(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := bucketSession()
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "dynamodb" {
+		return lock.NewDynamoDBBackend(sess, u.Host), nil
+	}
+	return lock.NewS3Backend(s3.New(sess), u.Host, strings.TrimPrefix(u.Path, "/")), nil
+}
+
+// lockLost returns err as an *interruptedError if the lock was lost, which stopped the run.
+func lockLost(l *lock.Lock, err error) error {
+	if err == nil || l.Err() == nil {
+		return err
+	}
+	return &interruptedError{err: err, cause: l.Err()}
+}
+
+// releaseLock releases the lock, if any. The lock is taken over after its TTL if it cannot be released.
+func releaseLock(l *lock.Lock) {
+	if l == nil {
+		return
+	}
+	if err := l.Release(); err != nil {
+		log.Printf("failed to release the lock: %s\n", err)
+	}
+}
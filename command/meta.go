@@ -1,8 +1,173 @@
 package command
 
-import "github.com/mitchellh/cli"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/mitchellh/cli"
+	awspkg "github.com/munisystem/rosculus/aws"
+	"github.com/munisystem/rosculus/aws/s3"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/dns/cloudflare"
+	"github.com/munisystem/rosculus/dns/dnsimple"
+	"github.com/munisystem/rosculus/dns/rfc2136"
+	"github.com/munisystem/rosculus/dns/route53"
+	"github.com/munisystem/rosculus/secret"
+)
 
 // Meta contain the meta-option that nearly all subcommand inherited.
 type Meta struct {
 	Ui cli.Ui
 }
+
+// configLocation returns the location and the name of the config given by the -config flag or
+// the NAME argument. NAME alone is the object NAME.yml in the S3 bucket in AWS_S3_BUCKET_NAME.
+// With -config, NAME is optional and defaults to the file name of the location without its extension.
+func configLocation(location string, args []string) (string, string, error) {
+	if len(args) > 1 {
+		return "", "", errors.New("too many arguments")
+	}
+
+	if location == "" {
+		if len(args) == 0 {
+			return "", "", errors.New("too few arguments")
+		}
+		bucket := os.Getenv("AWS_S3_BUCKET_NAME")
+		if bucket == "" {
+			return "", "", errors.New("please set s3 bucket name in AWS_S3_BUCKET_NAME")
+		}
+		return fmt.Sprintf("s3://%s/%s.yml", bucket, args[0]), args[0], nil
+	}
+
+	if len(args) == 1 {
+		return location, args[0], nil
+	}
+	if location == config.StdinPath {
+		return "", "", errors.New("NAME is required to read the config from the standard input")
+	}
+	name := path.Base(location)
+	return location, strings.TrimSuffix(name, path.Ext(name)), nil
+}
+
+// loadConfig loads the config named name at location for a run at now, which is one of
+//
+//	s3://BUCKET/KEY     an object read with bucketSession, which may be another account than the ones of the config
+//	https://HOST/PATH   a file downloaded over HTTP(S)
+//	file://PATH, PATH   a local file
+//	-                   the standard input
+//
+// The templates in the config refer to name as {{ .Name }} and to the date of now as {{ .Date }}.
+func (m *Meta) loadConfig(ctx context.Context, location, name string, now time.Time) (*config.Config, error) {
+	source, configPath, err := m.configSource(location)
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(ctx, source, configPath, &config.Variables{Name: name, Time: now})
+}
+
+// prepareConfig loads and validates the config named name at location for a run at now, and resolves its
+// secrets. It returns the config and the session of the account and the region which the clones live in.
+func (m *Meta) prepareConfig(ctx context.Context, location, name string, now time.Time) (*config.Config, *session.Session, error) {
+	cfg, err := m.loadConfig(ctx, location, name, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config file from %s: %w", location, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("config %s is invalid: %w", name, err)
+	}
+
+	sess, err := m.targetSession(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the AWS session of the target account: %w", err)
+	}
+
+	if err := cfg.ResolveSecrets(ctx, secret.NewResolver(sess)); err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve the secrets of config %s: %w", name, err)
+	}
+
+	return cfg, sess, nil
+}
+
+// configSource returns the source of the config at location and the path of the config in it.
+func (m *Meta) configSource(location string) (config.Source, string, error) {
+	if location == config.StdinPath {
+		return config.NewReaderSource(os.Stdin), config.StdinPath, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch u.Scheme {
+	case "s3":
+		sess, err := bucketSession()
+		if err != nil {
+			return nil, "", err
+		}
+		return config.NewS3Source(s3.New(sess), u.Host), strings.TrimPrefix(u.Path, "/"), nil
+	case "http", "https":
+		return config.NewHTTPSource(http.DefaultClient), location, nil
+	case "file":
+		// file://./staging.yml puts the leading dot in the host.
+		return config.NewFileSource(), u.Host + u.Path, nil
+	case "":
+		return config.NewFileSource(), location, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported config location %s", location)
+	}
+}
+
+// bucketSession returns the session described by the AWS_S3_BUCKET_REGION, AWS_S3_BUCKET_PROFILE,
+// AWS_S3_BUCKET_ROLE_ARN and AWS_S3_BUCKET_EXTERNAL_ID environment variables, which the configs
+// and the state in S3 are accessed with.
+func bucketSession() (*session.Session, error) {
+	return awspkg.NewSession(&awspkg.Config{
+		Region:     os.Getenv("AWS_S3_BUCKET_REGION"),
+		Profile:    os.Getenv("AWS_S3_BUCKET_PROFILE"),
+		RoleARN:    os.Getenv("AWS_S3_BUCKET_ROLE_ARN"),
+		ExternalID: os.Getenv("AWS_S3_BUCKET_EXTERNAL_ID"),
+	})
+}
+
+// targetSession returns the session of the account and the region which the clones of the config live in.
+func (m *Meta) targetSession(cfg *config.Config) (*session.Session, error) {
+	return awspkg.NewSession(accountConfig(cfg.CrossAccount.Target))
+}
+
+// accountConfig returns the AWS config of the account.
+func accountConfig(account config.Account) *awspkg.Config {
+	return &awspkg.Config{
+		Region:               account.Region,
+		Profile:              account.Profile,
+		RoleARN:              account.RoleARN,
+		ExternalID:           account.ExternalID,
+		RoleSessionName:      account.RoleSessionName,
+		WebIdentityTokenFile: account.WebIdentityTokenFile,
+	}
+}
+
+// dnsClient returns the client of the DNS provider described by the config, whose errors are *dns.Error.
+// Route 53 is managed with sess.
+func (m *Meta) dnsClient(cfg *config.Config, sess *session.Session) dns.DNS {
+	switch cfg.DNSProvider() {
+	case config.DNSProviderRoute53:
+		return dns.WrapErrors("Route 53", route53.NewClient(sess, cfg.Route53.HostedZoneID))
+	case config.DNSProviderCloudflare:
+		return dns.WrapErrors("Cloudflare", cloudflare.NewClient(cfg.Cloudflare.APIToken, cfg.Cloudflare.ZoneID))
+	case config.DNSProviderRFC2136:
+		return dns.WrapErrors("RFC 2136", rfc2136.NewClient(cfg.RFC2136.Server, cfg.RFC2136.TSIGKeyName, cfg.RFC2136.TSIGAlgorithm, cfg.RFC2136.TSIGSecret))
+	default:
+		return dns.WrapErrors("DNSimple", dnsimple.NewClient(cfg.DNSimple.AuthToken, cfg.DNSimple.AccountID))
+	}
+}
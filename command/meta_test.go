@@ -0,0 +1,44 @@
+package command
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfigLocation(t *testing.T) {
+	os.Setenv("AWS_S3_BUCKET_NAME", "configs")
+	defer os.Unsetenv("AWS_S3_BUCKET_NAME")
+
+	cases := []struct {
+		flag         string
+		args         []string
+		wantLocation string
+		wantName     string
+	}{
+		{"", []string{"staging"}, "s3://configs/staging.yml", "staging"},
+		{"./staging.yml", nil, "./staging.yml", "staging"},
+		{"https://example.com/configs/staging.yaml", nil, "https://example.com/configs/staging.yaml", "staging"},
+		{"s3://other/staging.yml", []string{"production"}, "s3://other/staging.yml", "production"},
+		{"-", []string{"staging"}, "-", "staging"},
+	}
+
+	for _, tc := range cases {
+		location, name, err := configLocation(tc.flag, tc.args)
+		if err != nil {
+			t.Errorf("-config %q %v: %s", tc.flag, tc.args, err)
+			continue
+		}
+		if location != tc.wantLocation || name != tc.wantName {
+			t.Errorf("-config %q %v: got %s %s, want %s %s", tc.flag, tc.args, location, name, tc.wantLocation, tc.wantName)
+		}
+	}
+
+	for _, args := range [][]string{nil, {"a", "b"}} {
+		if _, _, err := configLocation("", args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+	if _, _, err := configLocation("-", nil); err == nil {
+		t.Error("-config -: expected an error without NAME")
+	}
+}
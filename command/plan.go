@@ -0,0 +1,99 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+	"github.com/munisystem/rosculus/dns"
+)
+
+// plan prints the actions which the rotation would take.
+// It only looks up the current resources and never changes anything. failed are the clones whose rotations failed.
+func (c *RotateCommand) plan(ctx context.Context, config *config.Config, rdsClient *rds.Client, dnsClient dns.DNS, dbIdentifier string, failed map[string]bool, now time.Time) error {
+	kind := databaseKind(config)
+
+	c.Ui.Output("rosculus will perform the following actions:\n")
+
+	if config.CrossAccount.Enabled() {
+		c.Ui.Output(fmt.Sprintf("  + share and copy a snapshot into the target account as %s", dbIdentifier))
+		c.Ui.Output(fmt.Sprintf("  + restore %s %s from %s", kind, dbIdentifier, describeRestoreSource(config, "", nil)))
+		c.Ui.Output(fmt.Sprintf("  - delete the copied snapshot %s", dbIdentifier))
+	} else {
+		snapshotIdentifier, restoreTime, err := restoreSource(ctx, rdsClient, config)
+		if err != nil {
+			return err
+		}
+		c.Ui.Output(fmt.Sprintf("  + restore %s %s from %s", kind, dbIdentifier, describeRestoreSource(config, snapshotIdentifier, restoreTime)))
+	}
+
+	if config.IsDBInstance() {
+		c.Ui.Output(fmt.Sprintf("  ~ modify %s %s (class: %s, publicly accessible: %t, security groups: %v, master password)",
+			kind, dbIdentifier, config.DBInstanceClass, config.PubliclyAccessible, config.VPCSecurityGroupIds))
+	} else {
+		c.Ui.Output(fmt.Sprintf("  ~ modify %s %s (security groups: %v, master password)",
+			kind, dbIdentifier, config.VPCSecurityGroupIds))
+
+		memberIdentifier := rds.ClusterInstanceIdentifier(dbIdentifier)
+		member, err := rdsClient.DescribeDBInstance(ctx, memberIdentifier)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			c.Ui.Output(fmt.Sprintf("  + create RDS Instance %s (class: %s) in %s %s", memberIdentifier, config.DBInstanceClass, kind, dbIdentifier))
+		} else {
+			c.Ui.Output(fmt.Sprintf("  = use RDS Instance %s which already exists in %s %s", memberIdentifier, kind, dbIdentifier))
+		}
+	}
+
+	for i, query := range config.Queries {
+		c.Ui.Output(fmt.Sprintf("  + run query #%d on %s: %s", i+1, dbIdentifier, query))
+	}
+
+	if config.Credentials.Generate {
+		c.Ui.Output(fmt.Sprintf("  + generate a master user password for %s", dbIdentifier))
+	}
+	for _, sink := range config.Credentials.Sinks {
+		c.Ui.Output(fmt.Sprintf("  + publish the credentials of %s to %s", dbIdentifier, sink))
+	}
+
+	record := config.DNSRecord()
+	current, err := dnsClient.GetRecord(ctx, record.Domain, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+
+	value := fmt.Sprintf("(endpoint of %s)", dbIdentifier)
+	if current == nil {
+		c.Ui.Output(fmt.Sprintf("  + create %s record %s.%s -> %s (TTL: %d)", record.Type, record.Name, record.Domain, value, record.TTL))
+	} else {
+		c.Ui.Output(fmt.Sprintf("  ~ update %s record %s.%s: %s -> %s (TTL: %d)", record.Type, record.Name, record.Domain, current.Value, value, record.TTL))
+	}
+	if !config.Propagation.Skip {
+		resolver := "the authoritative nameservers"
+		if config.Propagation.Resolver != "" {
+			resolver = config.Propagation.Resolver
+		}
+		wait := fmt.Sprintf("  = wait until %s.%s resolves to the new value on %s", record.Name, record.Domain, resolver)
+		if current != nil {
+			wait += fmt.Sprintf(" and then for the TTL of the old record (%ds)", current.TTL)
+		}
+		c.Ui.Output(wait)
+	}
+
+	generations, err := listGenerations(ctx, rdsClient, config)
+	if err != nil {
+		return err
+	}
+	expired := expiredGenerations(config, generations, dbIdentifier, failed, now)
+	if len(expired) == 0 {
+		c.Ui.Output(fmt.Sprintf("  = no previous %s to delete", kind))
+	}
+	for _, prevDBIdentifier := range expired {
+		c.Ui.Output(fmt.Sprintf("  - delete %s %s", kind, prevDBIdentifier))
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/database/rds"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/state"
+)
+
+type RollbackCommand struct {
+	Meta
+}
+
+func (c *RollbackCommand) Run(args []string) int {
+	var (
+		configLocationFlag string
+		lockTimeout        time.Duration
+	)
+
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	flags.StringVar(&configLocationFlag, "config", "", "")
+	flags.DurationVar(&lockTimeout, "lock-timeout", 0, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	location, name, err := configLocation(configLocationFlag, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitUsage
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	if err := c.rollback(ctx, location, name, lockTimeout); err != nil {
+		return c.fail(interrupted(ctx, err))
+	}
+	return exitOK
+}
+
+func (c *RollbackCommand) rollback(ctx context.Context, location, name string, lockTimeout time.Duration) error {
+	config, sess, err := c.prepareConfig(ctx, location, name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return withLock(ctx, config, name, lockTimeout, func(ctx context.Context) error {
+		return c.roll(ctx, config, sess, name)
+	})
+}
+
+// roll points the DNS record of the config named name back at the previous clone and deletes the current one.
+func (c *RollbackCommand) roll(ctx context.Context, config *config.Config, sess *session.Session, name string) error {
+	rdsClient := rds.New(sess)
+
+	kind := databaseKind(config)
+	record := config.DNSRecord()
+
+	if err := checkPropagation(ctx, config, sess); err != nil {
+		return fmt.Errorf("config %s cannot verify the propagation of the record: %w", name, err)
+	}
+	dnsClient := c.dnsClient(config, sess)
+	current, err := dnsClient.GetRecord(ctx, record.Domain, record.Name, record.Type)
+	if err != nil {
+		return fmt.Errorf("failed to look up DNS record %s: %w", record.Name, err)
+	}
+
+	generations, err := listGenerations(ctx, rdsClient, config)
+	if err != nil {
+		return fmt.Errorf("failed to list the %ss: %w", kind, err)
+	}
+
+	store, err := stateStore(config)
+	if err != nil {
+		return fmt.Errorf("failed to open the state of config %s: %w", name, err)
+	}
+	var rotations []*state.Rotation
+	if store != nil {
+		if rotations, err = store.List(ctx, name); err != nil {
+			return fmt.Errorf("failed to read the rotations of config %s: %w", name, err)
+		}
+	}
+	// The clones of failed rotations may not have run the queries, so the record is never pointed back at them.
+	failed := failedGenerations(rotations)
+
+	var (
+		dbIdentifier     string
+		prevDBIdentifier string
+		prevInstance     *database.DBInstance
+	)
+	for i := len(generations) - 1; i >= 0; i-- {
+		identifier := generations[i].Identifier
+		instance, err := describeGeneration(ctx, rdsClient, config, identifier)
+		if err != nil {
+			return fmt.Errorf("failed to get informations of %s %s: %w", kind, identifier, err)
+		}
+		if instance == nil || instance.URL == "" {
+			continue
+		}
+
+		if dbIdentifier == "" {
+			if pointsAt(current, instance.URL) {
+				dbIdentifier = identifier
+			}
+			continue
+		}
+		if failed[identifier] {
+			log.Printf("skipped %s %s, whose rotation did not succeed\n", kind, identifier)
+			continue
+		}
+		prevDBIdentifier = identifier
+		prevInstance = instance
+		break
+	}
+
+	if dbIdentifier == "" {
+		return conflictf("DNS record %s.%s does not point at any %s of config %s", record.Name, record.Domain, kind, name)
+	}
+	if prevInstance == nil {
+		return conflictf("there is no previous %s older than %s, set Retention.KeepGenerations to 2 or more to keep it", kind, dbIdentifier)
+	}
+
+	// The sinks are switched before the record, so that no client reads the credentials of the clone to be deleted.
+	setPassword := func(ctx context.Context, password string) error {
+		return setGenerationPassword(ctx, rdsClient, config, prevDBIdentifier, password)
+	}
+	if err := republishCredentials(ctx, sess, config, prevDBIdentifier, prevInstance, setPassword); err != nil {
+		return fmt.Errorf("failed to publish the credentials of %s %s: %w", kind, prevDBIdentifier, err)
+	}
+
+	value, err := recordValue(record.Type, prevInstance.URL)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the %s record of %s: %w", record.Type, prevInstance.URL, err)
+	}
+	newRecord := &dns.Record{Type: record.Type, Name: record.Name, Value: value, TTL: record.TTL}
+	if err := dnsClient.UpdateRecord(ctx, record.Domain, newRecord); err != nil {
+		return fmt.Errorf("failed to update DNS record %s: %w", record.Name, err)
+	}
+	log.Printf("updated DNS record %s.%s to %s %s\n", record.Name, record.Domain, kind, prevDBIdentifier)
+
+	if store != nil {
+		if rotation := state.Find(rotations, dbIdentifier); rotation != nil {
+			rotation.Status = state.StatusRolledBack
+			rotation.UpdatedAt = time.Now()
+			if err := store.Put(ctx, rotation); err != nil {
+				return fmt.Errorf("failed to record the rollback of rotation %s: %w", rotation.ID, err)
+			}
+		}
+	}
+
+	// The record no longer points at the clone, which gc deletes if the rollback stops before deleting it.
+	if err := waitForPropagation(ctx, config, record.Domain, newRecord, current); err != nil {
+		return fmt.Errorf("failed to verify DNS record %s: %w", record.Name, err)
+	}
+
+	if err := deleteGeneration(ctx, rdsClient, config, dbIdentifier); err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, dbIdentifier, err)
+	}
+	log.Printf("deleted %s %s\n", kind, dbIdentifier)
+
+	return nil
+}
+
+func (c *RollbackCommand) Synopsis() string {
+	return "Point the DNS record back at the previous clone"
+}
+
+func (c *RollbackCommand) Help() string {
+	helpText := `
+Usage: rosculus rollback [options] [NAME]
+
+  Point the DNS record of the config NAME back at the previous clone and
+  delete the clone which the record pointed at. The previous clone is only
+  kept when Retention.KeepGenerations is 2 or more. The rotation which created
+  the deleted clone is recorded as rolled back in State.Location, and the
+  clones whose rotations recorded there did not succeed are never rolled
+  back to. When Credentials.Sinks are configured, the credentials of the
+  previous clone are published to them before the record is switched, with a
+  new master user password set on the clone if Credentials.Generate is true.
+
+Options:
+
+  -config=LOCATION    Read the config from LOCATION instead of NAME.yml in the
+                      S3 bucket in AWS_S3_BUCKET_NAME. See rosculus rotate -h.
+
+  -lock-timeout=0s    Wait up to the duration for another run on the config to
+                      release the lock instead of failing at once.
+`
+	return strings.TrimSpace(helpText)
+}
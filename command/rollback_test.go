@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+)
+
+func TestRollbackCommand_implement(t *testing.T) {
+	var _ cli.Command = &RollbackCommand{}
+}
+
+func TestRepublishCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sink := filepath.Join(dir, "credentials.json")
+
+	// The sink holds the credentials of the clone rolled back from.
+	if err := ioutil.WriteFile(sink, []byte(`{"host":"db-20170102.rds.amazonaws.com","password":"current"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		SourceDBInstanceIdentifier: "source",
+		DBInstanceIdentifier:       "db",
+		Credentials:                config.Credentials{Generate: true, Sinks: []string{"file:" + sink}},
+	}
+	previous := &database.DBInstance{URL: "db-20170101.rds.amazonaws.com", Port: 5432, Database: "app", User: "master"}
+
+	var set string
+	setPassword := func(ctx context.Context, password string) error {
+		set = password
+		return nil
+	}
+	if err := republishCredentials(context.Background(), nil, cfg, "db-20170101", previous, setPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got credentials
+	if err := json.Unmarshal(buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := credentials{
+		Username:             "master",
+		Password:             set,
+		Host:                 "db-20170101.rds.amazonaws.com",
+		Port:                 5432,
+		DBName:               "app",
+		DBInstanceIdentifier: "db-20170101",
+	}
+	if set == "" || len(set) != defaultPasswordLength {
+		t.Errorf("got the new password %q", set)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if previous.Password != "" {
+		t.Error("the instance is changed")
+	}
+}
@@ -1,17 +1,17 @@
 package command
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/munisystem/rosculus/config"
-	"github.com/munisystem/rosculus/database"
 	"github.com/munisystem/rosculus/database/rds"
-	"github.com/munisystem/rosculus/dns/dnsimple"
-	"github.com/munisystem/rosculus/lib/postgres"
+	"github.com/munisystem/rosculus/state"
 )
 
 type RotateCommand struct {
@@ -19,123 +19,208 @@ type RotateCommand struct {
 }
 
 func (c *RotateCommand) Run(args []string) int {
-	if len(args) == 0 {
-		log.Fatalln("too few arguments")
-	} else if len(args) > 1 {
-		log.Fatalln("too many arguments")
+	var (
+		plan               bool
+		resume             bool
+		configLocationFlag string
+		lockTimeout        time.Duration
+		timeout            time.Duration
+	)
+
+	flags := flag.NewFlagSet("rotate", flag.ContinueOnError)
+	flags.BoolVar(&plan, "plan", false, "")
+	flags.BoolVar(&resume, "resume", false, "")
+	flags.DurationVar(&lockTimeout, "lock-timeout", 0, "")
+	flags.DurationVar(&timeout, "timeout", 0, "")
+	flags.StringVar(&configLocationFlag, "config", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
 	}
 
-	name := args[0]
+	location, name, err := configLocation(configLocationFlag, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitUsage
+	}
 
-	bucket := os.Getenv("AWS_S3_BUCKET_NAME")
-	if bucket == "" {
-		log.Fatalln("please set s3 bucket name in AWS_S3_BUCKET_NAME")
+	ctx, cancel := runContext(timeout)
+	defer cancel()
+
+	if err := c.rotate(ctx, location, name, plan, resume, lockTimeout); err != nil {
+		return c.fail(interrupted(ctx, err))
 	}
+	return exitOK
+}
 
-	config, err := config.Load(bucket, name)
+func (c *RotateCommand) rotate(ctx context.Context, location, name string, plan, resume bool, lockTimeout time.Duration) error {
+	now := time.Now()
+	config, sess, err := c.prepareConfig(ctx, location, name, now)
 	if err != nil {
-		log.Fatalf("failed to load config file from S3: %s\n", err)
+		return err
 	}
 
-	var (
-		dbIdentifier     string
-		prevDBIdentifier string
-		instance         *database.DBInstance
-	)
-	now := time.Now()
+	run := func(ctx context.Context) error {
+		return c.run(ctx, config, sess, location, name, plan, resume, now)
+	}
+	// A plan changes nothing and needs no lock.
+	if plan {
+		return run(ctx)
+	}
+	// Keep the other runs on the config from naming their clones and changing the record at the same time.
+	return withLock(ctx, config, name, lockTimeout, run)
+}
 
-	if config.SourceDBInstanceIdentifier != "" && config.DBInstanceIdentifier != "" {
-		dbIdentifier = fmt.Sprintf("%s-%s", config.DBInstanceIdentifier, now.Format("20060102"))
-		prevDBIdentifier = fmt.Sprintf("%s-%s", config.DBInstanceIdentifier, now.Add(-24*time.Hour).Format("20060102"))
-
-		dbInstanceConfig := &rds.DBInstanceConfig{
-			SourceDBInstanceIdentifier: config.SourceDBInstanceIdentifier,
-			TargetDBInstanceIdentifier: dbIdentifier,
-			AvailabilityZone:           config.AvailabilityZone,
-			PubliclyAccessible:         config.PubliclyAccessible,
-			DBInstanceClass:            config.DBInstanceClass,
-			DBSubnetGroupName:          config.DBSubnetGroupName,
-			VpcSecurityGroupIds:        config.VPCSecurityGroupIds,
-			Tags:                       config.DBInstanceTags,
-			MasterUserPassword:         config.DBMasterUserPassword,
+// run rotates the clones of the config named name at location, or prints the plan of the rotation.
+func (c *RotateCommand) run(ctx context.Context, config *config.Config, sess *session.Session, location, name string, plan, resume bool, now time.Time) (err error) {
+	rdsClient := rds.New(sess)
+
+	rec := &recorder{}
+	defer func() {
+		if err == nil {
+			return
+		}
+		rec.finish(state.StatusFailed, err)
+		if ctx.Err() != nil && rec.store != nil && rec.rotation != nil {
+			log.Printf("stopped the rotation of %s %s, run rotate -resume to continue it\n", databaseKind(config), rec.rotation.Identifier)
 		}
+	}()
 
-		instance, err = rds.CloneDBInstance(dbInstanceConfig)
-	} else if config.SourceDBClusterIdentifier != "" && config.DBClusterIdentifier != "" {
-		dbIdentifier = fmt.Sprintf("%s-%s", config.DBClusterIdentifier, now.Format("20060102"))
-		prevDBIdentifier = fmt.Sprintf("%s-%s", config.DBClusterIdentifier, now.Add(-24*time.Hour).Format("20060102"))
-
-		dbClusterConfig := &rds.DBClusterConfig{
-			SourceDBClusterIdentifier: config.SourceDBClusterIdentifier,
-			DBClusterIdentifier:       dbIdentifier,
-			AvailabilityZone:          config.AvailabilityZone,
-			PubliclyAccessible:        config.PubliclyAccessible,
-			DBInstanceClass:           config.DBInstanceClass,
-			DBSubnetGroupName:         config.DBSubnetGroupName,
-			VpcSecurityGroupIds:       config.VPCSecurityGroupIds,
-			Tags:                      config.DBInstanceTags,
-			MasterUserPassword:        config.DBMasterUserPassword,
+	if rec.store, err = stateStore(config); err != nil {
+		return fmt.Errorf("failed to open the state of config %s: %w", name, err)
+	}
+	var (
+		last   *state.Rotation
+		failed = map[string]bool{}
+	)
+	if rec.store != nil {
+		rotations, err := rec.store.List(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to read the rotations of config %s: %w", name, err)
+		}
+		if len(rotations) != 0 {
+			last = rotations[len(rotations)-1]
 		}
+		failed = failedGenerations(rotations)
+	}
 
-		instance, err = rds.CloneDBCluster(dbClusterConfig)
+	var rotation *state.Rotation
+	if resume {
+		switch {
+		case rec.store == nil:
+			return errStateRequired("resume a rotation")
+		case last == nil || last.Finished():
+			return conflictf("there is no rotation of config %s to resume", name)
+		}
+		rotation = last
+		// The templates of the config, such as the tags, render as they did when the rotation started.
+		if config, sess, err = c.prepareConfig(ctx, location, name, rotation.StartedAt); err != nil {
+			return err
+		}
+		rdsClient = rds.New(sess)
+		resumeRotation(config, rotation)
+		log.Printf("resume the rotation of %s %s after the steps %s\n", databaseKind(config), last.Identifier, strings.Join(last.Steps, ", "))
 	} else {
-		log.Fatalf("config %s is invalid\n", name)
+		if last != nil && !last.Finished() {
+			log.Printf("the rotation of %s %s did not finish, run with -resume to continue it instead\n", databaseKind(config), last.Identifier)
+		}
+
+		generations, err := listGenerations(ctx, rdsClient, config)
+		if err != nil {
+			return fmt.Errorf("failed to list the previous %ss: %w", databaseKind(config), err)
+		}
+		dbIdentifier, sequence, err := nextGeneration(config, generations, now)
+		if err != nil {
+			return fmt.Errorf("failed to name the new %s: %w", databaseKind(config), err)
+		}
+		// Never take over a database which is not a clone of the config.
+		if existing, err := describeGeneration(ctx, rdsClient, config, dbIdentifier); err != nil {
+			return fmt.Errorf("failed to get informations of %s %s: %w", databaseKind(config), dbIdentifier, err)
+		} else if existing != nil {
+			return conflictf("%s %s already exists but is not a clone of config %s", databaseKind(config), dbIdentifier, name)
+		}
+		rotation = state.NewRotation(name, dbIdentifier, sequence, now)
 	}
 
+	password, err := masterUserPassword(config)
 	if err != nil {
-		log.Fatalf("failed to create Database: %s\n", err)
+		return fmt.Errorf("failed to generate the master user password: %w", err)
 	}
 
-	if len(config.Queries) != 0 {
-		connectionString := fmt.Sprintf(
-			"postgres://%s:%s@%s:%d/%s",
-			instance.User,
-			instance.Password,
-			instance.URL,
-			instance.Port,
-			instance.Database,
-		)
-		p := postgres.Initialize(connectionString)
-
-		if err := p.RunQueries(config.Queries); err != nil {
-			log.Fatalf("failed to execute queries: %s\n", err)
-		}
-
-		log.Println("executed queries")
+	if err := checkPropagation(ctx, config, sess); err != nil {
+		return fmt.Errorf("config %s cannot verify the propagation of the record: %w", name, err)
 	}
+	dnsClient := c.dnsClient(config, sess)
 
-	authToken := config.DNSimple.AuthToken
-	accountId := config.DNSimple.AccountID
-	domain := config.DNSimple.Domain
-	recordName := config.DNSimple.RecordName
-	ttl := config.DNSimple.TTL
+	if plan {
+		if err := c.plan(ctx, config, rdsClient, dnsClient, rotation.Identifier, failed, now); err != nil {
+			return fmt.Errorf("failed to make a plan: %w", err)
+		}
+		return nil
+	}
 
-	dnsClient := dnsimple.NewClient(authToken, accountId)
-	if err := dnsClient.UpdateRecord(domain, recordName, instance.URL, ttl); err != nil {
-		log.Fatalf("failed to update DNS record %s: %s \n", recordName, err)
+	rec.rotation = rotation
+	if err := rec.save(ctx); err != nil {
+		return err
 	}
-	log.Printf("updated DNS record %s.%s\n", recordName, domain)
 
-	if config.SourceDBInstanceIdentifier != "" && config.DBInstanceIdentifier != "" {
-		if err := rds.DeleteDBInstance(prevDBIdentifier); err != nil {
-			log.Fatalf("failed to delete the previous DB Instance %s: %s\n", prevDBIdentifier, err)
-		}
-	} else if config.SourceDBClusterIdentifier != "" && config.DBClusterIdentifier != "" {
-		if err := rds.DeleteDBCluster(prevDBIdentifier); err != nil {
-			log.Fatalf("failed to delete the previous DB Cluster %s: %s\n", prevDBIdentifier, err)
-		}
+	r := &rotator{
+		config:    config,
+		sess:      sess,
+		rdsClient: rdsClient,
+		dnsClient: dnsClient,
+		rec:       rec,
+		password:  password,
+		failed:    failed,
+		now:       now,
+	}
+	if err := r.run(ctx); err != nil {
+		return err
 	}
 
-	return 0
+	rec.finish(state.StatusSucceeded, nil)
+	return nil
 }
 
 func (c *RotateCommand) Synopsis() string {
-	return ""
+	return "Clone the source database and point the DNS record at the clone"
 }
 
 func (c *RotateCommand) Help() string {
 	helpText := `
-
+Usage: rosculus rotate [options] [NAME]
+
+  Clone the source database described by the config NAME, run the queries,
+  point the DNS record at the clone and delete the previous clones which fall
+  outside of the retention. Every run creates a new clone, even when the
+  previous one was created within the precision of Naming.Layout. The config
+  is NAME.yml in the S3 bucket in AWS_S3_BUCKET_NAME unless -config is given.
+  The rotation is recorded in State.Location if the config sets it. Runs on
+  the same config are kept apart by the lock in Lock.Location. SIGINT and
+  SIGTERM stop the rotation in the middle of its current step, which -resume
+  runs again.
+
+Options:
+
+  -config=LOCATION    Read the config from LOCATION instead, which is
+                      s3://BUCKET/KEY, https://HOST/PATH, file://PATH, a local
+                      path or - for the standard input. NAME defaults to the
+                      file name of LOCATION without its extension.
+
+  -lock-timeout=0s    Wait up to the duration for another run on the config to
+                      release the lock instead of failing at once.
+
+  -plan               Print the actions to be taken without changing anything.
+
+  -resume             Continue the last rotation recorded in State.Location
+                      which did not finish, from the step after the last one
+                      which completed. The completed steps, such as Queries,
+                      are not run again. The templates of the config render with the
+                      time the rotation started, and the DNS record which it
+                      recorded is the one changed.
+
+  -timeout=0s         Stop the rotation when it takes longer than the duration,
+                      like SIGINT does. 0 waits for the rotation to finish.
 `
 	return strings.TrimSpace(helpText)
 }
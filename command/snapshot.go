@@ -0,0 +1,121 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	awspkg "github.com/munisystem/rosculus/aws"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database/rds"
+)
+
+// snapshotFinder finds the snapshot which a clone is restored from. It is implemented by *rds.Client.
+type snapshotFinder interface {
+	FindDBSnapshot(ctx context.Context, dbInstanceIdentifier, prefix string, tags map[string]string) (string, error)
+	FindDBClusterSnapshot(ctx context.Context, dbClusterIdentifier, prefix string, tags map[string]string) (string, error)
+}
+
+// restoreSource returns the snapshot or the point in time which the clone of the config is restored from.
+// Both are empty if the source is restored to its latest restorable time.
+func restoreSource(ctx context.Context, finder snapshotFinder, config *config.Config) (string, *time.Time, error) {
+	snapshot := config.Snapshot
+
+	switch {
+	case snapshot.Identifier != "":
+		return snapshot.Identifier, nil, nil
+	case snapshot.Prefix != "" || len(snapshot.Tags) != 0:
+		var (
+			identifier string
+			err        error
+		)
+		if config.IsDBInstance() {
+			identifier, err = finder.FindDBSnapshot(ctx, config.SourceDBInstanceIdentifier, snapshot.Prefix, snapshot.Tags)
+		} else {
+			identifier, err = finder.FindDBClusterSnapshot(ctx, config.SourceDBClusterIdentifier, snapshot.Prefix, snapshot.Tags)
+		}
+		return identifier, nil, err
+	case !snapshot.RestoreTime.IsZero():
+		restoreTime := snapshot.RestoreTime
+		return "", &restoreTime, nil
+	default:
+		return "", nil, nil
+	}
+}
+
+// describeRestoreSource returns the human readable description of what the clone is restored from.
+func describeRestoreSource(config *config.Config, snapshotIdentifier string, restoreTime *time.Time) string {
+	source := config.SourceDBInstanceIdentifier
+	if config.IsDBCluster() {
+		source = config.SourceDBClusterIdentifier
+	}
+
+	switch {
+	case config.CrossAccount.Enabled():
+		from := "the default account"
+		if config.CrossAccount.Source.RoleARN != "" {
+			from = config.CrossAccount.Source.RoleARN
+		} else if config.CrossAccount.Source.Profile != "" {
+			from = "profile " + config.CrossAccount.Source.Profile
+		}
+		if config.CrossAccount.Source.Region != "" {
+			from += " in " + config.CrossAccount.Source.Region
+		}
+		return fmt.Sprintf("a snapshot of %s copied from %s", source, from)
+	case snapshotIdentifier != "":
+		return fmt.Sprintf("snapshot %s", snapshotIdentifier)
+	case restoreTime != nil:
+		return fmt.Sprintf("%s at %s", source, restoreTime.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("%s at the latest restorable time", source)
+	}
+}
+
+// copySnapshot shares a snapshot of the source with the target account of the config and copies it there.
+// target is the session of the target account. It returns the identifier of the copy, which is named after
+// the clone of the generation sequence.
+func copySnapshot(ctx context.Context, target *session.Session, config *config.Config, dbIdentifier string, sequence int) (string, error) {
+	if !config.Snapshot.RestoreTime.IsZero() {
+		return "", errors.New("Snapshot.RestoreTime cannot be used with CrossAccount.Source")
+	}
+
+	accounts := config.CrossAccount
+	accountID, err := awspkg.AccountID(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	source, err := awspkg.NewSession(accountConfig(accounts.Source))
+	if err != nil {
+		return "", err
+	}
+	rdsClient := rds.New(target)
+
+	crossAccountConfig := &rds.CrossAccountConfig{
+		Source:                   rds.New(source),
+		SnapshotIdentifier:       config.Snapshot.Identifier,
+		SnapshotPrefix:           config.Snapshot.Prefix,
+		SnapshotTags:             config.Snapshot.Tags,
+		SourceKMSKeyID:           accounts.Source.KMSKeyID,
+		TargetAccountID:          accountID,
+		TargetKMSKeyID:           accounts.Target.KMSKeyID,
+		TargetSnapshotIdentifier: dbIdentifier,
+		Tags:                     cloneTags(config, sequence),
+	}
+
+	if config.IsDBInstance() {
+		crossAccountConfig.SourceIdentifier = config.SourceDBInstanceIdentifier
+		return rdsClient.CopyDBSnapshotAcrossAccounts(ctx, crossAccountConfig)
+	}
+	crossAccountConfig.SourceIdentifier = config.SourceDBClusterIdentifier
+	return rdsClient.CopyDBClusterSnapshotAcrossAccounts(ctx, crossAccountConfig)
+}
+
+// deleteSnapshot deletes the snapshot of the clone of the config.
+func deleteSnapshot(ctx context.Context, rdsClient *rds.Client, config *config.Config, snapshotIdentifier string) error {
+	if config.IsDBInstance() {
+		return rdsClient.DeleteDBSnapshot(ctx, snapshotIdentifier)
+	}
+	return rdsClient.DeleteDBClusterSnapshot(ctx, snapshotIdentifier)
+}
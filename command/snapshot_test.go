@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+)
+
+// fakeFinder records the lookups of snapshots and finds "<kind>:<source>:<prefix>".
+type fakeFinder struct {
+	calls []string
+}
+
+func (f *fakeFinder) FindDBSnapshot(ctx context.Context, dbInstanceIdentifier, prefix string, tags map[string]string) (string, error) {
+	f.calls = append(f.calls, "instance")
+	return "instance:" + dbInstanceIdentifier + ":" + prefix, nil
+}
+
+func (f *fakeFinder) FindDBClusterSnapshot(ctx context.Context, dbClusterIdentifier, prefix string, tags map[string]string) (string, error) {
+	f.calls = append(f.calls, "cluster")
+	return "cluster:" + dbClusterIdentifier + ":" + prefix, nil
+}
+
+func TestRestoreSource(t *testing.T) {
+	restoreTime := time.Date(2017, 5, 26, 3, 0, 0, 0, time.UTC)
+	instance := config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db"}
+	cluster := config.Config{SourceDBClusterIdentifier: "source", DBClusterIdentifier: "db"}
+
+	cases := []struct {
+		name        string
+		config      config.Config
+		snapshot    config.Snapshot
+		identifier  string
+		restoreTime *time.Time
+		calls       []string
+	}{
+		{"latest", instance, config.Snapshot{}, "", nil, nil},
+		{"identifier", instance, config.Snapshot{Identifier: "manual", Prefix: "nightly-", RestoreTime: restoreTime}, "manual", nil, nil},
+		{"prefix", instance, config.Snapshot{Prefix: "nightly-", RestoreTime: restoreTime}, "instance:source:nightly-", nil, []string{"instance"}},
+		{"tags", cluster, config.Snapshot{Tags: map[string]string{"env": "production"}}, "cluster:source:", nil, []string{"cluster"}},
+		{"restore time", cluster, config.Snapshot{RestoreTime: restoreTime}, "", &restoreTime, nil},
+	}
+
+	for _, tc := range cases {
+		c := tc.config
+		c.Snapshot = tc.snapshot
+		f := &fakeFinder{}
+
+		identifier, restoreTime, err := restoreSource(context.Background(), f, &c)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if identifier != tc.identifier || !reflect.DeepEqual(restoreTime, tc.restoreTime) {
+			t.Errorf("%s: got %q %v, want %q %v", tc.name, identifier, restoreTime, tc.identifier, tc.restoreTime)
+		}
+		if !reflect.DeepEqual(f.calls, tc.calls) {
+			t.Errorf("%s: got lookups %v, want %v", tc.name, f.calls, tc.calls)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/aws/s3"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/state"
+)
+
+// staleRotation is the time after which a running rotation which has not been updated
+// is considered to have been abandoned by its runner.
+const staleRotation = 24 * time.Hour
+
+// stateStore returns the store of the rotations of the config, or nil if State.Location is empty.
+// The store in S3 is accessed with bucketSession.
+func stateStore(cfg *config.Config) (state.Store, error) {
+	location := cfg.State.Location
+	if location == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(location, "s3://") {
+		return state.NewFileStore(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := bucketSession()
+	if err != nil {
+		return nil, err
+	}
+	return state.NewS3Store(s3.New(sess), u.Host, strings.TrimPrefix(u.Path, "/")), nil
+}
+
+// errStateRequired returns the error for a run which needs the rotations of the config to do what,
+// while the config does not set State.Location.
+func errStateRequired(what string) error {
+	return &config.ValidationError{Errors: []*config.FieldError{
+		{Field: "State.Location", Message: "is required to " + what},
+	}}
+}
+
+// recorder records a rotation in the store. It does nothing without a store or a rotation.
+// rotate -resume skips the steps which the record has completed, so failing to record a step fails the rotation.
+type recorder struct {
+	store    state.Store
+	rotation *state.Rotation
+}
+
+// save writes the current record of the rotation.
+func (r *recorder) save(ctx context.Context) error {
+	if r.store == nil || r.rotation == nil {
+		return nil
+	}
+	r.rotation.UpdatedAt = time.Now()
+	if err := r.store.Put(ctx, r.rotation); err != nil {
+		return fmt.Errorf("failed to record rotation %s: %w", r.rotation.ID, err)
+	}
+	return nil
+}
+
+// finish records that the rotation finished with the status and the error, if any.
+// The record is written even after the rotation was stopped by a signal or the timeout.
+// Failing to write it is only logged, as every step of the rotation is already recorded.
+func (r *recorder) finish(status string, err error) {
+	if r.rotation == nil {
+		return
+	}
+	r.rotation.Finish(status, time.Now())
+	if err != nil {
+		r.rotation.Error = err.Error()
+	}
+	if err := r.save(context.Background()); err != nil {
+		log.Println(err)
+	}
+}
+
+// failedGenerations returns the clones whose latest rotation did not succeed, on which the queries may not have run.
+// Neither the retention nor rollback counts them as previous clones.
+func failedGenerations(rotations []*state.Rotation) map[string]bool {
+	failed := map[string]bool{}
+	for _, rotation := range rotations {
+		failed[rotation.Identifier] = rotation.Status != state.StatusSucceeded
+	}
+	return failed
+}
+
+// runningRotations returns the clones which rotations still in progress at now are creating.
+func runningRotations(rotations []*state.Rotation, now time.Time) map[string]bool {
+	running := map[string]bool{}
+	for _, rotation := range rotations {
+		if rotation.Status == state.StatusRunning && now.Sub(rotation.UpdatedAt) < staleRotation {
+			running[rotation.Identifier] = true
+		}
+	}
+	return running
+}
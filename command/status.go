@@ -0,0 +1,125 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/munisystem/rosculus/state"
+)
+
+type StatusCommand struct {
+	Meta
+}
+
+func (c *StatusCommand) Run(args []string) int {
+	var (
+		limit              int
+		configLocationFlag string
+	)
+
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	flags.IntVar(&limit, "n", 10, "")
+	flags.StringVar(&configLocationFlag, "config", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	location, name, err := configLocation(configLocationFlag, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitUsage
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	if err := c.status(ctx, location, name, limit); err != nil {
+		return c.fail(interrupted(ctx, err))
+	}
+	return exitOK
+}
+
+func (c *StatusCommand) status(ctx context.Context, location, name string, limit int) error {
+	config, err := c.loadConfig(ctx, location, name, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load config file from %s: %w", location, err)
+	}
+
+	store, err := stateStore(config)
+	if err != nil {
+		return fmt.Errorf("failed to open the state of config %s: %w", name, err)
+	}
+	if store == nil {
+		return errStateRequired("show the rotations")
+	}
+
+	rotations, err := store.List(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to read the rotations of config %s: %w", name, err)
+	}
+	if len(rotations) == 0 {
+		c.Ui.Output(fmt.Sprintf("no rotation of config %s is recorded", name))
+		return nil
+	}
+	if limit > 0 && len(rotations) > limit {
+		rotations = rotations[len(rotations)-limit:]
+	}
+
+	c.Ui.Output(formatRotations(rotations))
+	return nil
+}
+
+// formatRotations returns a table of the rotations.
+func formatRotations(rotations []*state.Rotation) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tSTATUS\tLAST STEP\tIDENTIFIER\tENDPOINT\tRECORD\tERROR")
+	for _, r := range rotations {
+		record := "-"
+		if r.Record != nil {
+			record = fmt.Sprintf("%s.%s -> %s", r.Record.Name, r.Record.Domain, r.Record.Value)
+		}
+		endpoint := "-"
+		if r.Endpoint != "" {
+			endpoint = fmt.Sprintf("%s:%d", r.Endpoint, r.Port)
+		}
+		step := "-"
+		if len(r.Steps) != 0 {
+			step = r.Steps[len(r.Steps)-1]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.StartedAt.Format(time.RFC3339), r.Status, step, r.Identifier, endpoint, record, r.Error)
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (c *StatusCommand) Synopsis() string {
+	return "List the recorded rotations"
+}
+
+func (c *StatusCommand) Help() string {
+	helpText := `
+Usage: rosculus status [options] [NAME]
+
+  List the latest rotations of the config NAME recorded in State.Location,
+  oldest first, with their status, last completed step, clone, endpoint,
+  DNS record and error.
+  Neither RDS nor the DNS provider is accessed.
+
+Options:
+
+  -config=LOCATION    Read the config from LOCATION instead of NAME.yml in the
+                      S3 bucket in AWS_S3_BUCKET_NAME. See rosculus rotate -h.
+
+  -n=COUNT            List COUNT rotations at most. 0 lists all of them.
+                      Defaults to 10.
+`
+	return strings.TrimSpace(helpText)
+}
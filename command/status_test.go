@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/munisystem/rosculus/state"
+)
+
+func TestStatusCommand_implement(t *testing.T) {
+	var _ cli.Command = &StatusCommand{}
+}
+
+func TestStatusCommand_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stateDir := filepath.Join(dir, "state")
+	path := filepath.Join(dir, "staging.yml")
+	if err := ioutil.WriteFile(path, []byte(fmt.Sprintf("State:\n  Location: %s\n", stateDir)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &StatusCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{"-config", path}); code != 0 {
+		t.Fatalf("got exit code %d, want 0: %s", code, ui.ErrorWriter.String())
+	}
+	if want := "no rotation of config staging is recorded"; !strings.Contains(ui.OutputWriter.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, ui.OutputWriter.String())
+	}
+
+	store := state.NewFileStore(stateDir)
+	start := time.Date(2017, 1, 3, 3, 0, 0, 0, time.UTC)
+	for i, status := range []string{state.StatusSucceeded, state.StatusFailed, state.StatusRunning} {
+		r := state.NewRotation("staging", fmt.Sprintf("db-2017010%d", i+3), i+1, start.Add(time.Duration(i)*24*time.Hour))
+		r.Status = status
+		if status == state.StatusFailed {
+			r.Error = "failed to execute queries"
+		}
+		if err := store.Put(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui = new(cli.MockUi)
+	c = &StatusCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{"-config", path, "-n", "2"}); code != 0 {
+		t.Fatalf("got exit code %d, want 0: %s", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	if strings.Contains(output, "db-20170103") {
+		t.Errorf("output contains the rotation beyond -n:\n%s", output)
+	}
+	for _, want := range []string{"db-20170104", "failed to execute queries", "db-20170105", "running"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
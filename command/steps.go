@@ -0,0 +1,361 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/database/rds"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/lib/postgres"
+	"github.com/munisystem/rosculus/state"
+)
+
+// The steps of a rotation. Every completed step is recorded in the state,
+// from which rotate -resume continues a rotation which did not finish.
+const (
+	stepCopySnapshot   = "copy-snapshot"
+	stepRestore        = "restore"
+	stepModify         = "modify"
+	stepAddInstance    = "add-instance"
+	stepDeleteSnapshot = "delete-snapshot"
+	stepQueries        = "queries"
+	stepCredentials    = "credentials"
+	stepDNS            = "dns"
+	stepPropagation    = "propagation"
+	stepCleanup        = "cleanup"
+)
+
+type step struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// rotator runs the steps of the rotation recorded by rec.
+type rotator struct {
+	config    *config.Config
+	sess      *session.Session
+	rdsClient *rds.Client
+	dnsClient dns.DNS
+	rec       *recorder
+	// password is the master user password which the modify step sets.
+	password string
+	// failed are the clones whose rotations did not succeed, which the retention does not keep.
+	failed map[string]bool
+	now    time.Time
+}
+
+// steps returns the steps of the rotation of the config, in order.
+func (r *rotator) steps() []*step {
+	steps := []*step{}
+	if r.config.CrossAccount.Enabled() {
+		steps = append(steps, &step{stepCopySnapshot, r.copySnapshot})
+	}
+	steps = append(steps, &step{stepRestore, r.restore}, &step{stepModify, r.modify})
+	if r.config.IsDBCluster() {
+		steps = append(steps, &step{stepAddInstance, r.addInstance})
+	}
+	if r.config.CrossAccount.Enabled() {
+		steps = append(steps, &step{stepDeleteSnapshot, r.deleteSnapshot})
+	}
+	if len(r.config.Queries) != 0 {
+		steps = append(steps, &step{stepQueries, r.runQueries})
+	}
+	if len(r.config.Credentials.Sinks) != 0 {
+		steps = append(steps, &step{stepCredentials, r.publishCredentials})
+	}
+	return append(steps,
+		&step{stepDNS, r.updateRecord},
+		&step{stepPropagation, r.waitForPropagation},
+		&step{stepCleanup, r.deleteExpired},
+	)
+}
+
+// resumeRotation prepares the rotation which did not finish to be continued by run.
+func resumeRotation(config *config.Config, rotation *state.Rotation) {
+	rotation.Status = state.StatusRunning
+	rotation.Error = ""
+	// The generated password of the clone is not recorded, so a new one is set unless it has been published.
+	if config.Credentials.Generate && !rotation.Completed(stepCredentials) {
+		rotation.Undo(stepModify)
+	}
+}
+
+// run runs the steps of the rotation.
+func (r *rotator) run(ctx context.Context) error {
+	return r.runSteps(ctx, r.steps())
+}
+
+// runSteps runs the steps which have not completed yet, and records every one of them once it completes.
+// Once ctx is canceled, no further step starts.
+func (r *rotator) runSteps(ctx context.Context, steps []*step) error {
+	for _, s := range steps {
+		if r.rec.rotation.Completed(s.name) {
+			log.Printf("skipped step %s, which has completed\n", s.name)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to start step %s: %w", s.name, err)
+		}
+		if err := s.run(ctx); err != nil {
+			return err
+		}
+		r.rec.rotation.Complete(s.name)
+		// The step has completed even if ctx was canceled meanwhile, and must not run again on resume.
+		if err := r.rec.save(context.Background()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *rotator) identifier() string {
+	return r.rec.rotation.Identifier
+}
+
+func (r *rotator) copySnapshot(ctx context.Context) error {
+	// The snapshot is only needed until the clone is restored from it.
+	snapshotIdentifier, err := copySnapshot(ctx, r.sess, r.config, r.identifier(), r.rec.rotation.Sequence)
+	if err != nil {
+		return fmt.Errorf("failed to copy the snapshot from the source account: %w", err)
+	}
+	r.rec.rotation.SnapshotIdentifier = snapshotIdentifier
+	return nil
+}
+
+func (r *rotator) restore(ctx context.Context) error {
+	var (
+		snapshotIdentifier = r.rec.rotation.SnapshotIdentifier
+		restoreTime        *time.Time
+		err                error
+	)
+	if !r.config.CrossAccount.Enabled() {
+		if snapshotIdentifier, restoreTime, err = restoreSource(ctx, r.rdsClient, r.config); err != nil {
+			return fmt.Errorf("failed to find the snapshot to restore: %w", err)
+		}
+	}
+	r.rec.rotation.Source = describeRestoreSource(r.config, snapshotIdentifier, restoreTime)
+	if err := r.rec.save(ctx); err != nil {
+		return err
+	}
+
+	if r.config.IsDBInstance() {
+		dbInstanceConfig := r.dbInstanceConfig()
+		dbInstanceConfig.SnapshotIdentifier = snapshotIdentifier
+		dbInstanceConfig.RestoreTime = restoreTime
+		err = r.rdsClient.RestoreDBInstance(ctx, dbInstanceConfig)
+	} else {
+		dbClusterConfig := r.dbClusterConfig()
+		dbClusterConfig.SnapshotIdentifier = snapshotIdentifier
+		dbClusterConfig.RestoreTime = restoreTime
+		err = r.rdsClient.RestoreDBCluster(ctx, dbClusterConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create Database: %w", err)
+	}
+	return nil
+}
+
+func (r *rotator) modify(ctx context.Context) error {
+	var err error
+	if r.config.IsDBInstance() {
+		err = r.rdsClient.ModifyDBInstance(ctx, r.dbInstanceConfig())
+	} else {
+		err = r.rdsClient.ModifyDBCluster(ctx, r.dbClusterConfig())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to modify %s %s: %w", databaseKind(r.config), r.identifier(), err)
+	}
+
+	instance, err := r.instance(ctx)
+	if err != nil {
+		return err
+	}
+	r.rec.rotation.Endpoint = instance.URL
+	r.rec.rotation.Port = instance.Port
+	return nil
+}
+
+func (r *rotator) addInstance(ctx context.Context) error {
+	if err := r.rdsClient.AddDBInstanceToCluster(ctx, r.dbClusterConfig()); err != nil {
+		return fmt.Errorf("failed to add an RDS Instance to Aurora Cluster %s: %w", r.identifier(), err)
+	}
+	return nil
+}
+
+func (r *rotator) deleteSnapshot(ctx context.Context) error {
+	snapshotIdentifier := r.rec.rotation.SnapshotIdentifier
+	if err := deleteSnapshot(ctx, r.rdsClient, r.config, snapshotIdentifier); err != nil {
+		return fmt.Errorf("failed to delete the copied snapshot %s: %w", snapshotIdentifier, err)
+	}
+	log.Printf("deleted the copied snapshot %s\n", snapshotIdentifier)
+	return nil
+}
+
+// runQueries runs the queries in a transaction. The step is recorded as soon as the transaction commits,
+// so a resumed rotation never runs them twice unless the process dies in between.
+func (r *rotator) runQueries(ctx context.Context) error {
+	instance, err := r.instance(ctx)
+	if err != nil {
+		return err
+	}
+
+	connectionString := fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s",
+		instance.User,
+		instance.Password,
+		instance.URL,
+		instance.Port,
+		instance.Database,
+	)
+	p := postgres.Initialize(connectionString)
+
+	rows, err := p.RunQueries(ctx, r.config.Queries)
+	if err != nil {
+		return fmt.Errorf("failed to execute queries: %w", err)
+	}
+	for i, query := range r.config.Queries {
+		r.rec.rotation.Queries = append(r.rec.rotation.Queries, &state.QueryResult{Query: query, RowsAffected: rows[i]})
+	}
+
+	log.Println("executed queries")
+	return nil
+}
+
+func (r *rotator) publishCredentials(ctx context.Context) error {
+	instance, err := r.instance(ctx)
+	if err != nil {
+		return err
+	}
+	if err := publishCredentials(ctx, r.sess, r.config, r.identifier(), instance); err != nil {
+		return fmt.Errorf("failed to publish the credentials of %s: %w", r.identifier(), err)
+	}
+	log.Printf("published the credentials of %s\n", r.identifier())
+	return nil
+}
+
+// dnsRecord returns the record of the config, or the one which the rotation recorded before it changed it,
+// so that a resumed rotation changes the same record even if the config names another one now.
+func (r *rotator) dnsRecord() *config.Record {
+	record := r.config.DNSRecord()
+	if recorded := r.rec.rotation.Record; recorded != nil {
+		record.Domain, record.Name, record.Type = recorded.Domain, recorded.Name, recorded.Type
+	}
+	return record
+}
+
+func (r *rotator) updateRecord(ctx context.Context) error {
+	record := r.dnsRecord()
+
+	instance, err := r.instance(ctx)
+	if err != nil {
+		return err
+	}
+	value, err := recordValue(record.Type, instance.URL)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the %s record of %s: %w", record.Type, instance.URL, err)
+	}
+
+	// The record is recorded before it is updated, so that a resumed rotation
+	// still knows the previous value after the update.
+	if r.rec.rotation.Record == nil {
+		oldRecord, err := r.dnsClient.GetRecord(ctx, record.Domain, record.Name, record.Type)
+		if err != nil {
+			return fmt.Errorf("failed to look up DNS record %s: %w", record.Name, err)
+		}
+		r.rec.rotation.Record = &state.Record{Domain: record.Domain, Name: record.Name, Type: record.Type}
+		if oldRecord != nil {
+			r.rec.rotation.Record.PreviousValue = oldRecord.Value
+			r.rec.rotation.Record.PreviousTTL = oldRecord.TTL
+		}
+	}
+	r.rec.rotation.Record.Value = value
+	if err := r.rec.save(ctx); err != nil {
+		return err
+	}
+
+	newRecord := &dns.Record{Type: record.Type, Name: record.Name, Value: value, TTL: record.TTL}
+	if err := r.dnsClient.UpdateRecord(ctx, record.Domain, newRecord); err != nil {
+		return fmt.Errorf("failed to update DNS record %s: %w", record.Name, err)
+	}
+	log.Printf("updated DNS record %s.%s\n", record.Name, record.Domain)
+	return nil
+}
+
+func (r *rotator) waitForPropagation(ctx context.Context) error {
+	record := r.dnsRecord()
+	recorded := r.rec.rotation.Record
+
+	newRecord := &dns.Record{Type: record.Type, Name: record.Name, Value: recorded.Value, TTL: record.TTL}
+	var oldRecord *dns.Record
+	if recorded.PreviousValue != "" {
+		oldRecord = &dns.Record{Type: record.Type, Name: record.Name, Value: recorded.PreviousValue, TTL: recorded.PreviousTTL}
+	}
+	if err := waitForPropagation(ctx, r.config, record.Domain, newRecord, oldRecord); err != nil {
+		return fmt.Errorf("failed to verify DNS record %s: %w", record.Name, err)
+	}
+	return nil
+}
+
+func (r *rotator) deleteExpired(ctx context.Context) error {
+	kind := databaseKind(r.config)
+	generations, err := listGenerations(ctx, r.rdsClient, r.config)
+	if err != nil {
+		return fmt.Errorf("failed to list the previous %ss: %w", kind, err)
+	}
+	for _, prevDBIdentifier := range expiredGenerations(r.config, generations, r.identifier(), r.failed, r.now) {
+		if err := deleteGeneration(ctx, r.rdsClient, r.config, prevDBIdentifier); err != nil {
+			return fmt.Errorf("failed to delete the previous %s %s: %w", kind, prevDBIdentifier, err)
+		}
+		log.Printf("deleted the previous %s %s\n", kind, prevDBIdentifier)
+		r.rec.rotation.Deleted = append(r.rec.rotation.Deleted, prevDBIdentifier)
+		if err := r.rec.save(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instance returns the connection information of the clone with the master user password.
+func (r *rotator) instance(ctx context.Context) (*database.DBInstance, error) {
+	instance, err := describeGeneration(ctx, r.rdsClient, r.config, r.identifier())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get informations of %s %s: %w", databaseKind(r.config), r.identifier(), err)
+	} else if instance == nil {
+		return nil, conflictf("%s %s does not exist", databaseKind(r.config), r.identifier())
+	}
+	instance.Password = r.password
+	return instance, nil
+}
+
+func (r *rotator) dbInstanceConfig() *rds.DBInstanceConfig {
+	return &rds.DBInstanceConfig{
+		SourceDBInstanceIdentifier: r.config.SourceDBInstanceIdentifier,
+		TargetDBInstanceIdentifier: r.identifier(),
+		AvailabilityZone:           r.config.AvailabilityZone,
+		PubliclyAccessible:         r.config.PubliclyAccessible,
+		DBInstanceClass:            r.config.DBInstanceClass,
+		DBSubnetGroupName:          r.config.DBSubnetGroupName,
+		VpcSecurityGroupIds:        r.config.VPCSecurityGroupIds,
+		Tags:                       cloneTags(r.config, r.rec.rotation.Sequence),
+		MasterUserPassword:         r.password,
+	}
+}
+
+func (r *rotator) dbClusterConfig() *rds.DBClusterConfig {
+	return &rds.DBClusterConfig{
+		SourceDBClusterIdentifier: r.config.SourceDBClusterIdentifier,
+		DBClusterIdentifier:       r.identifier(),
+		AvailabilityZone:          r.config.AvailabilityZone,
+		PubliclyAccessible:        r.config.PubliclyAccessible,
+		DBInstanceClass:           r.config.DBInstanceClass,
+		DBSubnetGroupName:         r.config.DBSubnetGroupName,
+		VpcSecurityGroupIds:       r.config.VPCSecurityGroupIds,
+		Tags:                      cloneTags(r.config, r.rec.rotation.Sequence),
+		MasterUserPassword:        r.password,
+	}
+}
@@ -0,0 +1,179 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+	"github.com/munisystem/rosculus/state"
+)
+
+func TestRotatorSteps(t *testing.T) {
+	cases := []struct {
+		config *config.Config
+		steps  []string
+	}{
+		{
+			config: &config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db"},
+			steps:  []string{stepRestore, stepModify, stepDNS, stepPropagation, stepCleanup},
+		},
+		{
+			config: &config.Config{
+				SourceDBClusterIdentifier: "source",
+				DBClusterIdentifier:       "db",
+				CrossAccount:              config.CrossAccount{Source: config.Account{Profile: "production"}},
+				Queries:                   []string{"DELETE FROM sessions"},
+				Credentials:               config.Credentials{Generate: true, Sinks: []string{"ssm:/db/credentials"}},
+			},
+			steps: []string{
+				stepCopySnapshot, stepRestore, stepModify, stepAddInstance, stepDeleteSnapshot,
+				stepQueries, stepCredentials, stepDNS, stepPropagation, stepCleanup,
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		r := &rotator{config: tc.config}
+		steps := []string{}
+		for _, s := range r.steps() {
+			steps = append(steps, s.name)
+		}
+		if !reflect.DeepEqual(steps, tc.steps) {
+			t.Errorf("#%d: got %v, want %v", i, steps, tc.steps)
+		}
+	}
+}
+
+func TestRotatorRun_resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.Config{
+		SourceDBInstanceIdentifier: "source",
+		DBInstanceIdentifier:       "db",
+		Queries:                    []string{"DELETE FROM sessions"},
+		Credentials:                config.Credentials{Generate: true, Sinks: []string{"ssm:/db/credentials"}},
+	}
+	rotation := state.NewRotation("staging", "db-20170101", 1, time.Date(2017, 1, 1, 3, 0, 0, 0, time.UTC))
+	rotation.Steps = []string{stepRestore, stepModify, stepQueries}
+	rotation.Finish(state.StatusFailed, rotation.StartedAt)
+
+	// The password set by the modify step was not published, so modify runs again, but the queries do not.
+	resumeRotation(cfg, rotation)
+	if rotation.Status != state.StatusRunning {
+		t.Errorf("got status %s, want %s", rotation.Status, state.StatusRunning)
+	}
+
+	store := state.NewFileStore(dir)
+	r := &rotator{config: cfg, rec: &recorder{store: store, rotation: rotation}}
+	ran := []string{}
+	steps := r.steps()
+	for _, s := range steps {
+		name := s.name
+		s.run = func(ctx context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	if err := r.runSteps(context.Background(), steps); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{stepModify, stepCredentials, stepDNS, stepPropagation, stepCleanup}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+
+	rotations, err := store.List(context.Background(), "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range steps {
+		if len(rotations) != 1 || !rotations[0].Completed(s.name) {
+			t.Errorf("step %s is not recorded as completed", s.name)
+		}
+	}
+}
+
+func TestRotatorRun_canceled(t *testing.T) {
+	cfg := &config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db"}
+	rotation := state.NewRotation("staging", "db-20170101", 1, time.Date(2017, 1, 1, 3, 0, 0, 0, time.UTC))
+	r := &rotator{config: cfg, rec: &recorder{rotation: rotation}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	steps := r.steps()
+	for _, s := range steps {
+		s.run = func(context.Context) error {
+			// The lock is lost or the run is interrupted during the first step.
+			cancel()
+			return nil
+		}
+	}
+	if err := r.runSteps(ctx, steps); err == nil {
+		t.Fatal("expected the run to stop")
+	}
+	if want := []string{stepRestore}; !reflect.DeepEqual(rotation.Steps, want) {
+		t.Errorf("got completed steps %v, want %v", rotation.Steps, want)
+	}
+}
+
+// failingStore fails to write the records.
+type failingStore struct{}
+
+func (failingStore) Put(ctx context.Context, r *state.Rotation) error {
+	return errors.New("access denied")
+}
+
+func (failingStore) List(ctx context.Context, config string) ([]*state.Rotation, error) {
+	return []*state.Rotation{}, nil
+}
+
+func TestRotatorRun_unrecorded(t *testing.T) {
+	cfg := &config.Config{SourceDBInstanceIdentifier: "source", DBInstanceIdentifier: "db", Queries: []string{"DELETE FROM sessions"}}
+	rotation := state.NewRotation("staging", "db-20170101", 1, time.Date(2017, 1, 1, 3, 0, 0, 0, time.UTC))
+	r := &rotator{config: cfg, rec: &recorder{store: failingStore{}, rotation: rotation}}
+
+	ran := []string{}
+	steps := r.steps()
+	for _, s := range steps {
+		name := s.name
+		s.run = func(context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	// A resumed rotation would run the queries again if their completion was not recorded.
+	if err := r.runSteps(context.Background(), steps); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if want := []string{stepRestore}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestRotatorDNSRecord(t *testing.T) {
+	cfg := &config.Config{Route53: config.Route53{HostedZoneID: "ZONE", Domain: "example.com", RecordName: "db-20170102", TTL: 60}}
+	rotation := state.NewRotation("staging", "db-20170101", 1, time.Date(2017, 1, 1, 3, 0, 0, 0, time.UTC))
+	r := &rotator{config: cfg, rec: &recorder{rotation: rotation}}
+
+	want := &config.Record{Domain: "example.com", Name: "db-20170102", Type: "CNAME", TTL: 60}
+	if got := r.dnsRecord(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A resumed rotation changes the record which it recorded, even if the config names another one now.
+	rotation.Record = &state.Record{Domain: "example.com", Name: "db-20170101", Type: "CNAME", Value: "db-20170101.rds.amazonaws.com"}
+	want.Name = "db-20170101"
+	if got := r.dnsRecord(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/config"
+)
+
+type ValidateCommand struct {
+	Meta
+}
+
+func (c *ValidateCommand) Run(args []string) int {
+	var configLocationFlag string
+
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.StringVar(&configLocationFlag, "config", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	location, name, err := configLocation(configLocationFlag, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return exitUsage
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	cfg, err := c.loadConfig(ctx, location, name, time.Now())
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		verr, ok := err.(*config.ValidationError)
+		if !ok {
+			return c.fail(interrupted(ctx, fmt.Errorf("failed to load config file from %s: %w", location, err)))
+		}
+		c.Ui.Error(fmt.Sprintf("config %s has %d problem(s):", name, len(verr.Errors)))
+		for _, ferr := range verr.Errors {
+			c.Ui.Error("  " + ferr.Error())
+		}
+		return exitConfig
+	}
+
+	c.Ui.Output(fmt.Sprintf("config %s is valid", name))
+	return exitOK
+}
+
+func (c *ValidateCommand) Synopsis() string {
+	return "Check the config for problems without accessing the databases"
+}
+
+func (c *ValidateCommand) Help() string {
+	helpText := `
+Usage: rosculus validate [options] [NAME]
+
+  Check the config NAME for unknown fields and invalid values and list every
+  problem found with the path of its field. Neither RDS nor the DNS provider
+  is accessed, so a local config is validated without any AWS credentials.
+
+Options:
+
+  -config=LOCATION    Read the config from LOCATION instead of NAME.yml in the
+                      S3 bucket in AWS_S3_BUCKET_NAME. See rosculus rotate -h.
+`
+	return strings.TrimSpace(helpText)
+}
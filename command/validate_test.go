@@ -0,0 +1,102 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestValidateCommand_implement(t *testing.T) {
+	var _ cli.Command = &ValidateCommand{}
+}
+
+func TestValidateCommand_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		config string
+		code   int
+		output []string
+	}{
+		{
+			config: `
+SourceDBInstanceIdentifier: source
+DBInstanceIdentifier: clone
+DBSubnetGroupName: private
+DBInstanceClass: db.t3.medium
+Route53:
+  HostedZoneID: Z123
+  Domain: example.com
+  RecordName: db
+  TTL: 60
+`,
+			code:   0,
+			output: []string{"config valid is valid"},
+		},
+		{
+			config: `
+SourceDBInstanceIdentifier: source
+DBInstanceIdentifer: clone
+Snapshot:
+  Prefx: nightly
+`,
+			code:   3,
+			output: []string{"DBInstanceIdentifer: is not a known field", "Snapshot.Prefx: is not a known field"},
+		},
+		{
+			config: `
+SourceDBInstanceIdentifier: source
+DBInstanceIdentifier: clone
+SourceDBClusterIdentifier: source
+DBInstanceClass: r5.large
+DNSimple:
+  Domain: example.com
+  RecordType: MX
+  TTL: 5
+State:
+  Location: https://example.com/state
+Lock:
+  TTL: -5m
+`,
+			code: 3,
+			output: []string{
+				"DBClusterIdentifier: cannot be combined",
+				"DBSubnetGroupName: is required",
+				`DBInstanceClass: "r5.large" is not an instance class`,
+				"DNSimple.AuthToken: is required",
+				`DNSimple.RecordType: "MX" is not one of`,
+				"DNSimple.TTL: 5 is out of the range",
+				`State.Location: "https://example.com/state" is neither`,
+				"Lock.TTL: cannot be negative",
+			},
+		},
+	}
+
+	names := []string{"valid", "unknown", "invalid"}
+	for i, tc := range cases {
+		path := filepath.Join(dir, names[i]+".yml")
+		if err := ioutil.WriteFile(path, []byte(tc.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui := new(cli.MockUi)
+		c := &ValidateCommand{Meta: Meta{Ui: ui}}
+		if code := c.Run([]string{"-config", path}); code != tc.code {
+			t.Errorf("%s: got exit code %d, want %d: %s", names[i], code, tc.code, ui.ErrorWriter.String())
+		}
+		output := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, want := range tc.output {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: output does not contain %q:\n%s", names[i], want, output)
+			}
+		}
+	}
+}
@@ -7,12 +7,36 @@ import (
 
 func Commands(meta *command.Meta) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
+		"gc": func() (cli.Command, error) {
+			return &command.GCCommand{
+				Meta: *meta,
+			}, nil
+		},
+
+		"rollback": func() (cli.Command, error) {
+			return &command.RollbackCommand{
+				Meta: *meta,
+			}, nil
+		},
+
 		"rotate": func() (cli.Command, error) {
 			return &command.RotateCommand{
 				Meta: *meta,
 			}, nil
 		},
 
+		"status": func() (cli.Command, error) {
+			return &command.StatusCommand{
+				Meta: *meta,
+			}, nil
+		},
+
+		"validate": func() (cli.Command, error) {
+			return &command.ValidateCommand{
+				Meta: *meta,
+			}, nil
+		},
+
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{
 				Meta:     *meta,
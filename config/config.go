@@ -1,45 +1,307 @@
 package config
 
 import (
-	"github.com/munisystem/rosculus/aws/s3"
+	"context"
+	"time"
+
+	"github.com/munisystem/rosculus/dns"
 	yaml "gopkg.in/yaml.v2"
 )
 
+// Config describes a source database, its clones and the DNS record pointed at the newest clone.
+// DBMasterUserPassword, DNSimple.AuthToken, Cloudflare.APIToken and RFC2136.TSIGSecret can be
+// references to secrets such as "ssm:/path", "secretsmanager:arn", "env:VAR" or "file:/path",
+// which are replaced by ResolveSecrets. Every string field can refer to environment variables
+// as "${NAME}" and be a template such as "{{ .Name }}-{{ .Date }}", which are expanded by Load.
+// The templates are executed before the environment variables are replaced, so their values are
+// taken literally. The secrets and Queries are not templates, and may contain "{{" literally.
 type Config struct {
+	// Extends is the config files which the file extends, relative to it.
+	// Load merges the file onto them in order: mappings are merged key by key, and lists and other values are replaced.
+	Extends                    []string          `yaml:"Extends"`
 	SourceDBInstanceIdentifier string            `yaml:"SourceDBInstanceIdentifier"`
 	DBInstanceIdentifier       string            `yaml:"DBInstanceIdentifier"`
 	SourceDBClusterIdentifier  string            `yaml:"SourceDBClusterIdentifier"`
 	DBClusterIdentifier        string            `yaml:"DBClusterIdentifier"`
-	DBMasterUserPassword       string            `yaml:"DBMasterUserPassword"`
+	Snapshot                   Snapshot          `yaml:"Snapshot"`
+	Naming                     Naming            `yaml:"Naming"`
+	DBMasterUserPassword       string            `yaml:"DBMasterUserPassword" interpolate:"env"`
+	Credentials                Credentials       `yaml:"Credentials"`
 	DBInstanceTags             map[string]string `yaml:"DBInstanceTags"`
 	AvailabilityZone           string            `yaml:"AvailabilityZone"`
 	DBSubnetGroupName          string            `yaml:"DBSubnetGroupName"`
 	PubliclyAccessible         bool              `yaml:"PubliclyAccessible"`
 	DBInstanceClass            string            `yaml:"DBInstanceClass"`
+	CrossAccount               CrossAccount      `yaml:"CrossAccount"`
 	VPCSecurityGroupIds        []string          `yaml:"VPCSecurityGroupIds"`
 	DNSimple                   DNSimple          `yaml:"DNSimple"`
-	Queries                    []string          `yaml:"Queries"`
+	Cloudflare                 Cloudflare        `yaml:"Cloudflare"`
+	Route53                    Route53           `yaml:"Route53"`
+	RFC2136                    RFC2136           `yaml:"RFC2136"`
+	Propagation                Propagation       `yaml:"Propagation"`
+	Queries                    []string          `yaml:"Queries" interpolate:"env"`
+	Retention                  Retention         `yaml:"Retention"`
+	State                      State             `yaml:"State"`
+	Lock                       Lock              `yaml:"Lock"`
+}
+
+// Credentials describes a master user password generated for every clone and where it is published.
+type Credentials struct {
+	// Generate sets a random password on every clone instead of DBMasterUserPassword.
+	Generate bool `yaml:"Generate"`
+	// Length is the length of the generated password, 32 by default.
+	Length int `yaml:"Length"`
+	// Sinks are where the credentials and the endpoint of the new clone are published as JSON, each of
+	// "secretsmanager:NAME", "ssm:/PATH", "s3://BUCKET/KEY" or "file:/PATH", which is written with 0600 permissions.
+	Sinks []string `yaml:"Sinks"`
+}
+
+// Snapshot describes what the clone is restored from.
+// The source is restored to its latest restorable time if it is empty.
+type Snapshot struct {
+	// Identifier is the identifier or the ARN of a manual or automated snapshot.
+	Identifier string `yaml:"Identifier"`
+	// Prefix and Tags select the newest snapshot of the source
+	// whose identifier starts with Prefix and which has every tag in Tags.
+	Prefix string            `yaml:"Prefix"`
+	Tags   map[string]string `yaml:"Tags"`
+	// RestoreTime restores the source to the point in time, e.g. "2017-05-26T03:00:00Z".
+	RestoreTime time.Time `yaml:"RestoreTime"`
+}
+
+// CrossAccount describes the accounts and the regions of the source and the clone.
+// If Source is set, a snapshot of the source is shared with the target account,
+// copied there and the clone is restored from the copy.
+type CrossAccount struct {
+	Source Account `yaml:"Source"`
+	Target Account `yaml:"Target"`
+}
+
+// Account describes an AWS account and a region.
+type Account struct {
+	// Profile is the profile in the shared config and credentials files.
+	// The default credentials are used if it is empty.
+	Profile string `yaml:"Profile"`
+	// RoleARN is assumed to access the account with the credentials of Profile.
+	RoleARN string `yaml:"RoleARN"`
+	// ExternalID is passed to AssumeRole if the trust policy of RoleARN requires it.
+	ExternalID string `yaml:"ExternalID"`
+	// RoleSessionName defaults to "rosculus".
+	RoleSessionName string `yaml:"RoleSessionName"`
+	// WebIdentityTokenFile assumes RoleARN with the OIDC token in the file, e.g. on EKS.
+	WebIdentityTokenFile string `yaml:"WebIdentityTokenFile"`
+	// Region defaults to the region of the default credentials.
+	Region string `yaml:"Region"`
+	// KMSKeyID re-encrypts the snapshot in the account.
+	// The key in the source account has to be usable by the target account.
+	KMSKeyID string `yaml:"KMSKeyID"`
+}
+
+// Enabled reports whether the source is in another account or region than the clone.
+func (c *CrossAccount) Enabled() bool {
+	return c.Source.Profile != "" || c.Source.RoleARN != "" || c.Source.Region != ""
 }
 
 type DNSimple struct {
-	AuthToken  string `yaml:"AuthToken"`
+	AuthToken  string `yaml:"AuthToken" interpolate:"env"`
 	AccountID  string `yaml:"AccountID"`
 	Domain     string `yaml:"Domain"`
 	RecordID   int    `yaml:"RecordID"`
 	RecordName string `yaml:"RecordName"`
+	RecordType string `yaml:"RecordType"`
+	TTL        int    `yaml:"TTL"`
+}
+
+// Cloudflare describes the record in a Cloudflare zone.
+// It is used instead of DNSimple when APIToken is set.
+// ZoneID is looked up by Domain if it is empty.
+type Cloudflare struct {
+	APIToken   string `yaml:"APIToken" interpolate:"env"`
+	ZoneID     string `yaml:"ZoneID"`
+	Domain     string `yaml:"Domain"`
+	RecordName string `yaml:"RecordName"`
+	RecordType string `yaml:"RecordType"`
 	TTL        int    `yaml:"TTL"`
 }
 
-func Load(bucket, name string) (*Config, error) {
+// Route53 describes the record in a Route 53 hosted zone.
+// It is used instead of DNSimple when HostedZoneID is set.
+type Route53 struct {
+	HostedZoneID string `yaml:"HostedZoneID"`
+	Domain       string `yaml:"Domain"`
+	RecordName   string `yaml:"RecordName"`
+	RecordType   string `yaml:"RecordType"`
+	TTL          int    `yaml:"TTL"`
+}
+
+// RFC2136 describes the record on an authoritative server which accepts dynamic updates, such as BIND.
+// It is used instead of DNSimple when Server is set.
+type RFC2136 struct {
+	// Server is the address of the authoritative server, e.g. "ns1.example.com:53".
+	Server        string `yaml:"Server"`
+	TSIGKeyName   string `yaml:"TSIGKeyName"`
+	TSIGSecret    string `yaml:"TSIGSecret" interpolate:"env"`
+	TSIGAlgorithm string `yaml:"TSIGAlgorithm"`
+	Domain        string `yaml:"Domain"`
+	RecordName    string `yaml:"RecordName"`
+	RecordType    string `yaml:"RecordType"`
+	TTL           int    `yaml:"TTL"`
+}
+
+// Propagation describes how a rotation verifies that the record points at the new clone
+// before it deletes the previous one.
+type Propagation struct {
+	// Skip disables the verification.
+	Skip bool `yaml:"Skip"`
+	// Resolver is the "host:port" of a recursive resolver to poll.
+	// The authoritative nameservers of the domain are polled if it is empty, so either it or Skip
+	// is required for a private Route 53 hosted zone, whose records the public nameservers do not answer.
+	Resolver string `yaml:"Resolver"`
+	// Timeout defaults to 10 minutes.
+	Timeout time.Duration `yaml:"Timeout"`
+	// Interval defaults to 10 seconds.
+	Interval time.Duration `yaml:"Interval"`
+}
+
+// Retention describes which clones are kept after a rotation.
+// A previous clone is deleted if it falls outside of either limit.
+type Retention struct {
+	// KeepGenerations is the number of clones kept, including the new one.
+	// Setting 2 or more keeps the previous clone available for rollback.
+	KeepGenerations int `yaml:"KeepGenerations"`
+	// MaxAge is the age after which a previous clone is deleted, e.g. "72h".
+	// Zero means no limit.
+	MaxAge time.Duration `yaml:"MaxAge"`
+}
+
+// State describes where the rotations are recorded.
+type State struct {
+	// Location is "s3://BUCKET/PREFIX" or a local directory.
+	// The rotations are not recorded if it is empty.
+	Location string `yaml:"Location"`
+}
+
+// Lock describes the lock which keeps the runs on the same config from changing the clones and the record at the same time.
+type Lock struct {
+	// Location is "s3://BUCKET/PREFIX", "dynamodb://TABLE" or a local directory. The table has the string
+	// partition key LockKey. A directory in the temporary directory is used if it is empty,
+	// which only keeps apart the runs on the same host.
+	Location string `yaml:"Location"`
+	// TTL is the lease of the lock, which its runner renews while it runs. The lock of a runner
+	// which died is taken over after it. Defaults to 5 minutes.
+	TTL time.Duration `yaml:"TTL"`
+}
+
+// IsDBInstance reports whether the config describes a clone of an RDS Instance.
+func (c *Config) IsDBInstance() bool {
+	return c.SourceDBInstanceIdentifier != "" && c.DBInstanceIdentifier != ""
+}
+
+// IsDBCluster reports whether the config describes a clone of an Aurora Cluster.
+func (c *Config) IsDBCluster() bool {
+	return c.SourceDBClusterIdentifier != "" && c.DBClusterIdentifier != ""
+}
+
+// DNS providers which the config can choose.
+const (
+	DNSProviderDNSimple   = "dnsimple"
+	DNSProviderCloudflare = "cloudflare"
+	DNSProviderRoute53    = "route53"
+	DNSProviderRFC2136    = "rfc2136"
+)
+
+// DNSProvider returns the DNS provider which holds the record pointed at the clone.
+func (c *Config) DNSProvider() string {
+	switch {
+	case c.Route53.HostedZoneID != "":
+		return DNSProviderRoute53
+	case c.Cloudflare.APIToken != "":
+		return DNSProviderCloudflare
+	case c.RFC2136.Server != "":
+		return DNSProviderRFC2136
+	default:
+		return DNSProviderDNSimple
+	}
+}
+
+// Record describes the DNS record pointed at the clone.
+type Record struct {
+	Domain string
+	Name   string
+	Type   string
+	TTL    int
+}
+
+// DNSRecord returns the record pointed at the clone in the zone of the DNS provider.
+// The record type defaults to CNAME.
+func (c *Config) DNSRecord() *Record {
+	var r *Record
+	switch c.DNSProvider() {
+	case DNSProviderRoute53:
+		r = &Record{Domain: c.Route53.Domain, Name: c.Route53.RecordName, Type: c.Route53.RecordType, TTL: c.Route53.TTL}
+	case DNSProviderCloudflare:
+		r = &Record{Domain: c.Cloudflare.Domain, Name: c.Cloudflare.RecordName, Type: c.Cloudflare.RecordType, TTL: c.Cloudflare.TTL}
+	case DNSProviderRFC2136:
+		r = &Record{Domain: c.RFC2136.Domain, Name: c.RFC2136.RecordName, Type: c.RFC2136.RecordType, TTL: c.RFC2136.TTL}
+	default:
+		r = &Record{Domain: c.DNSimple.Domain, Name: c.DNSimple.RecordName, Type: c.DNSimple.RecordType, TTL: c.DNSimple.TTL}
+	}
+	if r.Type == "" {
+		r.Type = dns.TypeCNAME
+	}
+	return r
+}
+
+// LoadError is returned when a config file cannot be read or parsed.
+type LoadError struct {
+	// Path is the path of the config file which Load was called with.
+	Path string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// Load reads the config file at path in the source, merged onto the config files which it extends.
+// The references to environment variables and the templates in its string fields are expanded with vars.
+// It returns a *ValidationError if the files have keys which are not fields of the config
+// or the expansion fails, and a *LoadError if the files cannot be read or parsed.
+// It does not Validate the values. It gives up reading when ctx is canceled.
+func Load(ctx context.Context, source Source, path string, vars *Variables) (*Config, error) {
+	c, err := load(ctx, source, path, vars)
+	if _, ok := err.(*ValidationError); err != nil && !ok {
+		return nil, &LoadError{Path: path, Err: err}
+	}
+	return c, err
+}
+
+func load(ctx context.Context, source Source, path string, vars *Variables) (*Config, error) {
 	c := &Config{}
 
-	key := name + ".yml"
-	buf, err := s3.Download(bucket, key)
+	m, extends, err := (&loader{source: source}).load(ctx, path)
 	if err != nil {
 		return nil, err
 	}
+	if len(extends) != 0 {
+		m[extendsKey] = extends
+	}
 
-	err = yaml.Unmarshal(buf, c)
+	doc, err := interpolate(m, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Marshalling the expanded document again keeps the decoding of durations and times to yaml.
+	buf, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.UnmarshalStrict(buf, c)
 	if err != nil {
 		return nil, err
 	}
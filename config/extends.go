@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// extendsKey is the key of the config files which a config file extends.
+const extendsKey = "Extends"
+
+// loader reads a config file and the config files which it extends from a source.
+type loader struct {
+	source Source
+	// stack is the paths of the files being read, to detect cycles.
+	stack []string
+}
+
+// load returns the YAML document of the config file at path merged onto the files which it extends,
+// and the refs of the files which it extends directly.
+func (l *loader) load(ctx context.Context, path string) (map[interface{}]interface{}, []string, error) {
+	for i, p := range l.stack {
+		if p == path {
+			cycle := append(append([]string{}, l.stack[i:]...), path)
+			return nil, nil, fmt.Errorf("config files extend each other: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	l.stack = append(l.stack, path)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	buf, err := l.source.Read(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", path, err)
+	}
+	m, ok := doc.(map[interface{}]interface{})
+	if doc != nil && !ok {
+		return nil, nil, fmt.Errorf("%s is not a mapping of the fields of the config", path)
+	}
+	if m == nil {
+		m = map[interface{}]interface{}{}
+	}
+
+	extends, err := extendsRefs(m[extendsKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", path, err)
+	}
+	delete(m, extendsKey)
+
+	if unknown := unknownFields(m); len(unknown) != 0 {
+		if len(l.stack) > 1 {
+			for _, ferr := range unknown {
+				ferr.Message += " in " + path
+			}
+		}
+		return nil, nil, &ValidationError{Errors: unknown}
+	}
+
+	merged := map[interface{}]interface{}{}
+	for _, ref := range extends {
+		base, _, err := l.load(ctx, l.source.Resolve(path, ref))
+		if err != nil {
+			return nil, nil, err
+		}
+		merge(merged, base)
+	}
+	merge(merged, m)
+
+	return merged, extends, nil
+}
+
+// extendsRefs returns the value of Extends, which is a ref or a list of refs.
+func extendsRefs(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		refs := make([]string, len(v))
+		for i, item := range v {
+			ref, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] is not a path", extendsKey, i)
+			}
+			refs[i] = ref
+		}
+		return refs, nil
+	default:
+		return nil, fmt.Errorf("%s is neither a path nor a list of paths", extendsKey)
+	}
+}
+
+// merge merges override into base. Mappings are merged key by key,
+// and lists and scalars in override replace the ones in base.
+func merge(base, override map[interface{}]interface{}) {
+	for key, value := range override {
+		b, ok1 := base[key].(map[interface{}]interface{})
+		o, ok2 := value.(map[interface{}]interface{})
+		if ok1 && ok2 {
+			merge(b, o)
+			continue
+		}
+		base[key] = value
+	}
+}
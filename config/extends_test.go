@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad_extends(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"shared/network.yml": `
+DBSubnetGroupName: private
+AvailabilityZone: ap-northeast-1a
+VPCSecurityGroupIds: [sg-shared, sg-db]
+`,
+		"shared/dns.yml": `
+DNSimple:
+  AuthToken: token
+  AccountID: "1234"
+  Domain: example.com
+  TTL: 60
+DBInstanceTags:
+  team: platform
+  env: default
+`,
+		"staging.yml": `
+Extends: [shared/network.yml, shared/dns.yml]
+SourceDBInstanceIdentifier: source
+DBInstanceIdentifier: staging
+VPCSecurityGroupIds: [sg-staging]
+DNSimple:
+  RecordName: staging-db
+DBInstanceTags:
+  env: staging
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	c, err := Load(context.Background(), NewFileSource(), filepath.Join(dir, "staging.yml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DBSubnetGroupName != "private" || c.AvailabilityZone != "ap-northeast-1a" {
+		t.Errorf("the fields of the base are not inherited: %+v", c)
+	}
+	if !reflect.DeepEqual(c.VPCSecurityGroupIds, []string{"sg-staging"}) {
+		t.Errorf("lists are not replaced: %v", c.VPCSecurityGroupIds)
+	}
+	if c.DNSimple.AuthToken != "token" || c.DNSimple.Domain != "example.com" || c.DNSimple.RecordName != "staging-db" {
+		t.Errorf("mappings are not merged: %+v", c.DNSimple)
+	}
+	if !reflect.DeepEqual(c.DBInstanceTags, map[string]string{"team": "platform", "env": "staging"}) {
+		t.Errorf("mappings are not merged: %v", c.DBInstanceTags)
+	}
+	if !reflect.DeepEqual(c.Extends, []string{"shared/network.yml", "shared/dns.yml"}) {
+		t.Errorf("unexpected Extends: %v", c.Extends)
+	}
+}
+
+func TestLoad_extendsErrors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yml":       "Extends: b.yml\n",
+		"b.yml":       "Extends: sub/c.yml\n",
+		"sub/c.yml":   "Extends: ../a.yml\n",
+		"unknown.yml": "Extends: typo.yml\n",
+		"typo.yml":    "DBSubnetGroupNmae: private\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := Load(context.Background(), NewFileSource(), filepath.Join(dir, "a.yml"), nil)
+	if err == nil || !strings.Contains(err.Error(), "extend each other") {
+		t.Errorf("got %v, want an error of the cycle", err)
+	}
+
+	_, err = Load(context.Background(), NewFileSource(), filepath.Join(dir, "unknown.yml"), nil)
+	if err == nil || !strings.Contains(err.Error(), "DBSubnetGroupNmae: is not a known field in ") {
+		t.Errorf("got %v, want an error of the unknown field", err)
+	}
+}
+
+func TestSourceResolve(t *testing.T) {
+	cases := []struct {
+		source Source
+		base   string
+		ref    string
+		want   string
+	}{
+		{&s3Source{}, "configs/staging.yml", "base.yml", "configs/base.yml"},
+		{&s3Source{}, "configs/staging.yml", "../shared/base.yml", "shared/base.yml"},
+		{&s3Source{}, "configs/staging.yml", "/base.yml", "base.yml"},
+		{&httpSource{}, "https://example.com/configs/staging.yml", "base.yml", "https://example.com/configs/base.yml"},
+		{&httpSource{}, "https://example.com/configs/staging.yml", "https://other.example.com/base.yml", "https://other.example.com/base.yml"},
+	}
+	for _, tc := range cases {
+		if got := tc.source.Resolve(tc.base, tc.ref); got != tc.want {
+			t.Errorf("%s from %s: got %s, want %s", tc.ref, tc.base, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// envPattern matches the references to environment variables, "${NAME}".
+// "$${NAME}" is the literal "${NAME}".
+var envPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Variables are the values which the templates in the string fields of a config file refer to.
+type Variables struct {
+	// Name is the name of the config.
+	Name string
+	// Time is the time of the rotation.
+	Time time.Time
+}
+
+// templateData is the data of the templates, e.g. "{{ .Name }}-{{ .Date }}".
+type templateData struct {
+	Name string
+	// Date is the date of the rotation in Naming.TimeZone, "20060102".
+	Date string
+	// Time is the time of the rotation in Naming.TimeZone.
+	Time time.Time
+}
+
+// interpolate executes the templates and then replaces the references to environment variables
+// in the string values of the YAML document whose fields are the string fields of the config.
+func interpolate(doc interface{}, vars *Variables) (interface{}, error) {
+	if vars == nil {
+		vars = &Variables{}
+	}
+	v := &validator{}
+	t := vars.Time.In(namingLocation(doc))
+	data := &templateData{Name: vars.Name, Date: t.Format("20060102"), Time: t}
+	doc = v.interpolate(doc, reflect.TypeOf(Config{}), "", data)
+	if len(v.errors) != 0 {
+		return nil, &ValidationError{Errors: v.errors}
+	}
+	return doc, nil
+}
+
+// namingLocation returns the location of Naming.TimeZone in the YAML document so that the dates of
+// the templates match the identifiers of the clones. An invalid time zone is reported by Validate.
+func namingLocation(doc interface{}) *time.Location {
+	m, _ := doc.(map[interface{}]interface{})
+	naming, _ := m["Naming"].(map[interface{}]interface{})
+	name, _ := naming["TimeZone"].(string)
+	if name == "" {
+		return time.Local
+	}
+	if expanded, err := expand(name, nil); err == nil {
+		name = expanded
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// interpolate expands the string values of node whose type is t.
+// The templates are not executed if data is nil.
+func (v *validator) interpolate(node interface{}, t reflect.Type, path string, data *templateData) interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		s, ok := node.(string)
+		if !ok {
+			return node
+		}
+		value, err := expand(s, data)
+		if err != nil {
+			v.add(strings.TrimSuffix(path, "."), err.Error())
+			return node
+		}
+		return value
+	case reflect.Struct, reflect.Map:
+		m, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return node
+		}
+		var fields map[string]reflect.Type
+		envOnly := map[string]bool{}
+		if t.Kind() == reflect.Struct {
+			fields = fieldTypes(t)
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+				switch field.Tag.Get("interpolate") {
+				case "-":
+					// Fields tagged `interpolate:"-"` are templates of their own.
+					delete(fields, name)
+				case "env":
+					// Fields tagged `interpolate:"env"` are secrets or SQL, which may contain "{{" literally.
+					envOnly[name] = true
+				}
+			}
+		}
+		for key, value := range m {
+			elem, fieldData := t, data
+			if t.Kind() == reflect.Map {
+				elem = t.Elem()
+			} else if elem, ok = fields[fmt.Sprint(key)]; !ok {
+				continue
+			} else if envOnly[fmt.Sprint(key)] {
+				fieldData = nil
+			}
+			m[key] = v.interpolate(value, elem, fmt.Sprintf("%s%v.", path, key), fieldData)
+		}
+		return m
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			return node
+		}
+		for i, item := range items {
+			items[i] = v.interpolate(item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i), data)
+		}
+		return items
+	default:
+		return node
+	}
+}
+
+// expand executes s as a template with data unless data is nil, and then replaces the references
+// to environment variables in the result. The values of environment variables are never executed.
+func expand(s string, data *templateData) (string, error) {
+	if data != nil && strings.Contains(s, "{{") {
+		tmpl, err := template.New("").Option("missingkey=error").Parse(s)
+		if err != nil {
+			return "", err
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, data); err != nil {
+			return "", err
+		}
+		s = buf.String()
+	}
+
+	var unset []string
+	s = envPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := envPattern.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return value
+	})
+	if len(unset) != 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(unset, ", "))
+	}
+	return s, nil
+}
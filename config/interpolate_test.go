@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_interpolate(t *testing.T) {
+	os.Setenv("ROSCULUS_TEST_ENV", "staging")
+	defer os.Unsetenv("ROSCULUS_TEST_ENV")
+
+	source := NewReaderSource(strings.NewReader(`
+SourceDBInstanceIdentifier: ${ROSCULUS_TEST_ENV}-db
+DBInstanceIdentifier: "{{ .Name }}"
+DBMasterUserPassword: $${NOT_EXPANDED}
+DBInstanceTags:
+  rotated-on: "{{ .Date }}"
+VPCSecurityGroupIds:
+  - sg-${ROSCULUS_TEST_ENV}
+Route53:
+  RecordName: db-{{ .Time.Format "2006" }}
+  TTL: 60
+Retention:
+  MaxAge: 72h
+`))
+	vars := &Variables{Name: "clone", Time: time.Date(2017, 5, 26, 3, 0, 0, 0, time.UTC)}
+
+	c, err := Load(context.Background(), source, StdinPath, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"SourceDBInstanceIdentifier", c.SourceDBInstanceIdentifier, "staging-db"},
+		{"DBInstanceIdentifier", c.DBInstanceIdentifier, "clone"},
+		{"DBMasterUserPassword", c.DBMasterUserPassword, "${NOT_EXPANDED}"},
+		{"DBInstanceTags.rotated-on", c.DBInstanceTags["rotated-on"], "20170526"},
+		{"VPCSecurityGroupIds[0]", c.VPCSecurityGroupIds[0], "sg-staging"},
+		{"Route53.RecordName", c.Route53.RecordName, "db-2017"},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.field, tc.got, tc.want)
+		}
+	}
+	if c.Route53.TTL != 60 || c.Retention.MaxAge != 72*time.Hour {
+		t.Errorf("non-string fields are changed: %+v %+v", c.Route53, c.Retention)
+	}
+}
+
+func TestLoad_interpolateErrors(t *testing.T) {
+	source := NewReaderSource(strings.NewReader(`
+DBInstanceIdentifier: ${ROSCULUS_TEST_UNSET}
+Route53:
+  RecordName: "{{ .Missing }}"
+`))
+
+	_, err := Load(context.Background(), source, StdinPath, nil)
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	fields := map[string]bool{}
+	for _, ferr := range verr.Errors {
+		fields[ferr.Field] = true
+	}
+	if !fields["DBInstanceIdentifier"] || !fields["Route53.RecordName"] {
+		t.Errorf("unexpected errors: %s", verr)
+	}
+}
+
+func TestLoad_interpolateLiterals(t *testing.T) {
+	os.Setenv("ROSCULUS_TEST_ENV", "{{ .Name }}")
+	defer os.Unsetenv("ROSCULUS_TEST_ENV")
+
+	source := NewReaderSource(strings.NewReader(`
+DBInstanceIdentifier: db-${ROSCULUS_TEST_ENV}
+DBMasterUserPassword: "p{{ss"
+DBInstanceTags:
+  rotated-on: "{{ .Date }}"
+  rotated-at: "{{ .Time.Format \"15\" }}"
+Naming:
+  TimeZone: Asia/Tokyo
+Cloudflare:
+  APIToken: "{{ .Name }}"
+Queries:
+  - SELECT '{{' || ${ROSCULUS_TEST_ENV}
+`))
+	vars := &Variables{Name: "clone", Time: time.Date(2017, 5, 26, 18, 0, 0, 0, time.UTC)}
+
+	c, err := Load(context.Background(), source, StdinPath, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"DBInstanceIdentifier", c.DBInstanceIdentifier, "db-{{ .Name }}"},
+		{"DBMasterUserPassword", c.DBMasterUserPassword, "p{{ss"},
+		{"DBInstanceTags.rotated-on", c.DBInstanceTags["rotated-on"], "20170527"},
+		{"DBInstanceTags.rotated-at", c.DBInstanceTags["rotated-at"], "03"},
+		{"Cloudflare.APIToken", c.Cloudflare.APIToken, "{{ .Name }}"},
+		{"Queries[0]", c.Queries[0], "SELECT '{{' || {{ .Name }}"},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.field, tc.got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+// Defaults of Naming, which name the clones "<base>-20060102".
+const (
+	DefaultNamingTemplate = "{{ .Base }}-{{ .Timestamp }}"
+	DefaultNamingLayout   = "20060102"
+)
+
+// Naming describes the identifiers of the clones.
+type Naming struct {
+	// Template is the Go template of the identifier of a clone, which is executed with NamingData
+	// when a rotation starts. Load does not expand it. It defaults to DefaultNamingTemplate.
+	Template string `yaml:"Template" interpolate:"-"`
+	// Layout is the Go time layout of .Timestamp, e.g. "20060102-1504" for hourly clones.
+	// It defaults to DefaultNamingLayout.
+	Layout string `yaml:"Layout"`
+	// TimeZone is the IANA time zone of .Timestamp and .Time, e.g. "Asia/Tokyo".
+	// It defaults to the local time zone.
+	TimeZone string `yaml:"TimeZone"`
+}
+
+// NamingData is the data of the naming template.
+type NamingData struct {
+	// Base is DBInstanceIdentifier or DBClusterIdentifier.
+	Base string
+	// Timestamp is Time in Layout.
+	Timestamp string
+	// Time is the time of the rotation in TimeZone.
+	Time time.Time
+	// Sequence is the number of the generation, which counts up from 1.
+	Sequence int
+}
+
+// Identifier returns the identifier of the clone of the generation sequence, whose rotation started at t.
+func (n *Naming) Identifier(base string, t time.Time, sequence int) (string, error) {
+	text := n.Template
+	if text == "" {
+		text = DefaultNamingTemplate
+	}
+	layout := n.Layout
+	if layout == "" {
+		layout = DefaultNamingLayout
+	}
+	loc := time.Local
+	if n.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(n.TimeZone); err != nil {
+			return "", err
+		}
+	}
+
+	tmpl, err := template.New("").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	t = t.In(loc)
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, &NamingData{Base: base, Timestamp: t.Format(layout), Time: t, Sequence: sequence}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/munisystem/rosculus/secret"
+)
+
+// secretField is a field of the config which holds a secret or a reference to it.
+type secretField struct {
+	path  string
+	value *string
+}
+
+func (c *Config) secretFields() []secretField {
+	return []secretField{
+		{"DBMasterUserPassword", &c.DBMasterUserPassword},
+		{"DNSimple.AuthToken", &c.DNSimple.AuthToken},
+		{"Cloudflare.APIToken", &c.Cloudflare.APIToken},
+		{"RFC2136.TSIGSecret", &c.RFC2136.TSIGSecret},
+	}
+}
+
+// SecretError is returned when the secret which a field refers to cannot be resolved.
+type SecretError struct {
+	// Field is the path of the field, e.g. "DNSimple.AuthToken".
+	Field string
+	Err   error
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("failed to resolve %s: %s", e.Field, e.Err)
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// ResolveSecrets replaces the references to secrets in the fields which hold secrets,
+// such as "ssm:/rosculus/password", with their values. Plain values are kept as they are.
+// It returns a *SecretError if a secret cannot be resolved, and gives up when ctx is canceled.
+func (c *Config) ResolveSecrets(ctx context.Context, r secret.Resolver) error {
+	for _, field := range c.secretFields() {
+		value, err := secret.Resolve(ctx, r, *field.value)
+		if err != nil {
+			return &SecretError{Field: field.path, Err: err}
+		}
+		*field.value = value
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/munisystem/rosculus/aws/s3"
+)
+
+// StdinPath is the path which the config read from the standard input is given.
+const StdinPath = "-"
+
+// Source reads config files. Read gives up when ctx is canceled.
+type Source interface {
+	// Read returns the content of the config file at path in the source.
+	Read(ctx context.Context, path string) ([]byte, error)
+	// Resolve returns the path of ref, which the config file at base refers to.
+	// Relative refs are relative to the directory of base.
+	Resolve(base, ref string) string
+}
+
+type s3Source struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Source returns the source which reads the objects of the bucket.
+// The paths are the keys of the objects.
+func NewS3Source(client *s3.Client, bucket string) Source {
+	return &s3Source{client: client, bucket: bucket}
+}
+
+func (s *s3Source) Read(ctx context.Context, path string) ([]byte, error) {
+	return s.client.Download(ctx, s.bucket, strings.TrimPrefix(path, "/"))
+}
+
+func (s *s3Source) Resolve(base, ref string) string {
+	if strings.HasPrefix(ref, "/") {
+		return strings.TrimPrefix(ref, "/")
+	}
+	return strings.TrimPrefix(pathpkg.Join(pathpkg.Dir("/"+base), ref), "/")
+}
+
+type fileSource struct{}
+
+// NewFileSource returns the source which reads the local filesystem.
+// Relative paths are relative to the working directory.
+func NewFileSource() Source {
+	return &fileSource{}
+}
+
+func (s *fileSource) Read(ctx context.Context, path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+func (s *fileSource) Resolve(base, ref string) string {
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(base), ref)
+}
+
+type httpSource struct {
+	client *http.Client
+}
+
+// NewHTTPSource returns the source which downloads the configs with the client.
+// The paths are HTTP or HTTPS URLs.
+func NewHTTPSource(client *http.Client) Source {
+	return &httpSource{client: client}
+}
+
+func (s *httpSource) Read(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", path, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *httpSource) Resolve(base, ref string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.ResolveReference(r).String()
+}
+
+type readerSource struct {
+	r io.Reader
+}
+
+// NewReaderSource returns the source which reads the only config from r, such as the standard input.
+// Its only path is StdinPath.
+func NewReaderSource(r io.Reader) Source {
+	return &readerSource{r: r}
+}
+
+func (s *readerSource) Read(ctx context.Context, path string) ([]byte, error) {
+	if path != StdinPath {
+		return nil, errors.New("the standard input has no config file at " + path)
+	}
+	return ioutil.ReadAll(s.r)
+}
+
+// Resolve returns ref as it is, which the standard input cannot read.
+func (s *readerSource) Resolve(base, ref string) string {
+	return ref
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+SourceDBInstanceIdentifier: source
+DBInstanceIdentifier: clone
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "staging.yml")
+	if err := ioutil.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/staging.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testConfig))
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		source Source
+		path   string
+	}{
+		{NewFileSource(), path},
+		{NewHTTPSource(ts.Client()), ts.URL + "/staging.yml"},
+		{NewReaderSource(strings.NewReader(testConfig)), StdinPath},
+	}
+
+	for _, tc := range cases {
+		c, err := Load(context.Background(), tc.source, tc.path, nil)
+		if err != nil {
+			t.Errorf("%s: %s", tc.path, err)
+			continue
+		}
+		if c.SourceDBInstanceIdentifier != "source" || c.DBInstanceIdentifier != "clone" {
+			t.Errorf("%s: unexpected config %+v", tc.path, c)
+		}
+	}
+
+	if _, err := Load(context.Background(), NewHTTPSource(ts.Client()), ts.URL+"/missing.yml", nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
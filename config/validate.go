@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/database"
+	"github.com/munisystem/rosculus/dns"
+	"github.com/munisystem/rosculus/secret"
+)
+
+// The range of the TTL of the record. Cloudflare also accepts 1, which means automatic.
+const (
+	minTTL = 30
+	maxTTL = 86400
+)
+
+// The range of the length of the generated passwords. MySQL accepts 41 characters at most.
+const (
+	minPasswordLength = 16
+	maxPasswordLength = 41
+)
+
+var (
+	// identifierPattern matches the identifiers of RDS Instances and Aurora Clusters.
+	identifierPattern = regexp.MustCompile(`^[a-zA-Z](-?[a-zA-Z0-9])*$`)
+	// instanceClassPattern matches instance classes such as "db.r5.large" and "db.serverless".
+	instanceClassPattern = regexp.MustCompile(`^db\.[a-z0-9]+(\.[a-z0-9]+)?$`)
+)
+
+// FieldError is a problem with a field of the config.
+type FieldError struct {
+	// Field is the path of the field, e.g. "DNSimple.TTL".
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every problem found in a config.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the config without accessing AWS or the DNS provider.
+// It returns a *ValidationError which lists every problem found.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	switch {
+	case c.IsDBInstance() && (c.SourceDBClusterIdentifier != "" || c.DBClusterIdentifier != ""):
+		v.add("DBClusterIdentifier", "cannot be combined with DBInstanceIdentifier, set either an RDS Instance or an Aurora Cluster")
+	case c.IsDBCluster() && (c.SourceDBInstanceIdentifier != "" || c.DBInstanceIdentifier != ""):
+		v.add("DBInstanceIdentifier", "cannot be combined with DBClusterIdentifier, set either an RDS Instance or an Aurora Cluster")
+	case c.IsDBInstance():
+		v.identifier("SourceDBInstanceIdentifier", c.SourceDBInstanceIdentifier)
+		v.identifier("DBInstanceIdentifier", c.DBInstanceIdentifier)
+	case c.IsDBCluster():
+		v.identifier("SourceDBClusterIdentifier", c.SourceDBClusterIdentifier)
+		v.identifier("DBClusterIdentifier", c.DBClusterIdentifier)
+	case c.SourceDBInstanceIdentifier != "" || c.DBInstanceIdentifier != "":
+		v.required("SourceDBInstanceIdentifier", c.SourceDBInstanceIdentifier)
+		v.required("DBInstanceIdentifier", c.DBInstanceIdentifier)
+	case c.SourceDBClusterIdentifier != "" || c.DBClusterIdentifier != "":
+		v.required("SourceDBClusterIdentifier", c.SourceDBClusterIdentifier)
+		v.required("DBClusterIdentifier", c.DBClusterIdentifier)
+	default:
+		v.add("DBInstanceIdentifier", "is required, set either SourceDBInstanceIdentifier and DBInstanceIdentifier or SourceDBClusterIdentifier and DBClusterIdentifier")
+	}
+
+	v.naming(c)
+
+	v.required("DBSubnetGroupName", c.DBSubnetGroupName)
+	if v.required("DBInstanceClass", c.DBInstanceClass) && !instanceClassPattern.MatchString(c.DBInstanceClass) {
+		v.add("DBInstanceClass", fmt.Sprintf("%q is not an instance class such as db.r5.large", c.DBInstanceClass))
+	}
+
+	if c.Credentials.Generate {
+		if c.DBMasterUserPassword != "" {
+			v.add("DBMasterUserPassword", "cannot be combined with Credentials.Generate")
+		}
+		if len(c.Credentials.Sinks) == 0 {
+			v.add("Credentials.Sinks", "is required to publish the generated password")
+		}
+	}
+	if length := c.Credentials.Length; length != 0 && (length < minPasswordLength || length > maxPasswordLength) {
+		v.add("Credentials.Length", fmt.Sprintf("%d is out of the range from %d to %d", length, minPasswordLength, maxPasswordLength))
+	}
+	for i, sink := range c.Credentials.Sinks {
+		if _, _, err := secret.ParseSink(sink); err != nil {
+			v.add(fmt.Sprintf("Credentials.Sinks[%d]", i), err.Error())
+		}
+	}
+
+	snapshot := c.Snapshot
+	if !snapshot.RestoreTime.IsZero() && (snapshot.Identifier != "" || snapshot.Prefix != "" || len(snapshot.Tags) != 0) {
+		v.add("Snapshot.RestoreTime", "cannot be combined with Snapshot.Identifier, Snapshot.Prefix or Snapshot.Tags")
+	}
+	if snapshot.Identifier != "" && (snapshot.Prefix != "" || len(snapshot.Tags) != 0) {
+		v.add("Snapshot.Identifier", "cannot be combined with Snapshot.Prefix or Snapshot.Tags")
+	}
+	if c.CrossAccount.Enabled() && !snapshot.RestoreTime.IsZero() {
+		v.add("Snapshot.RestoreTime", "cannot be used with CrossAccount.Source")
+	}
+	if c.CrossAccount.Source.WebIdentityTokenFile != "" {
+		v.required("CrossAccount.Source.RoleARN", c.CrossAccount.Source.RoleARN)
+	}
+	if c.CrossAccount.Target.WebIdentityTokenFile != "" {
+		v.required("CrossAccount.Target.RoleARN", c.CrossAccount.Target.RoleARN)
+	}
+
+	v.dns(c)
+
+	for _, field := range c.secretFields() {
+		if _, path, ok := secret.Parse(*field.value); ok && path == "" {
+			v.add(field.path, fmt.Sprintf("%q refers to no secret", *field.value))
+		}
+	}
+
+	if c.Propagation.Timeout < 0 {
+		v.add("Propagation.Timeout", "cannot be negative")
+	}
+	if c.Propagation.Interval < 0 {
+		v.add("Propagation.Interval", "cannot be negative")
+	}
+	if c.Retention.KeepGenerations < 0 {
+		v.add("Retention.KeepGenerations", "cannot be negative")
+	}
+	if c.Retention.MaxAge < 0 {
+		v.add("Retention.MaxAge", "cannot be negative")
+	}
+
+	if location := c.State.Location; strings.Contains(location, "://") {
+		if u, err := url.Parse(location); err != nil || u.Scheme != "s3" || u.Host == "" {
+			v.add("State.Location", fmt.Sprintf("%q is neither s3://BUCKET/PREFIX nor a local directory", location))
+		}
+	}
+
+	if location := c.Lock.Location; strings.Contains(location, "://") {
+		if u, err := url.Parse(location); err != nil || (u.Scheme != "s3" && u.Scheme != "dynamodb") || u.Host == "" {
+			v.add("Lock.Location", fmt.Sprintf("%q is neither s3://BUCKET/PREFIX, dynamodb://TABLE nor a local directory", location))
+		}
+	}
+	if c.Lock.TTL < 0 {
+		v.add("Lock.TTL", "cannot be negative")
+	}
+
+	if len(v.errors) != 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+type validator struct {
+	errors []*FieldError
+}
+
+func (v *validator) add(field, message string) {
+	v.errors = append(v.errors, &FieldError{Field: field, Message: message})
+}
+
+// required reports whether value is set, and adds a problem if it is not.
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) identifier(field, value string) {
+	if !identifierPattern.MatchString(value) {
+		v.add(field, fmt.Sprintf("%q is not an identifier of RDS, which consists of letters, digits and single hyphens and starts with a letter", value))
+	}
+}
+
+// naming checks that the naming template makes identifiers which RDS accepts.
+func (v *validator) naming(c *Config) {
+	base := c.DBInstanceIdentifier
+	maxLength := database.MaxIdentifierLength
+	if c.IsDBCluster() {
+		base = c.DBClusterIdentifier
+		// The RDS Instance in the Aurora Cluster is named after it.
+		maxLength -= len(database.ClusterInstanceSuffix)
+	}
+	if base == "" {
+		return
+	}
+
+	if c.Naming.TimeZone != "" {
+		if _, err := time.LoadLocation(c.Naming.TimeZone); err != nil {
+			v.add("Naming.TimeZone", err.Error())
+			return
+		}
+	}
+	identifier, err := c.Naming.Identifier(base, time.Now(), 1)
+	if err != nil {
+		v.add("Naming.Template", err.Error())
+		return
+	}
+	if !identifierPattern.MatchString(identifier) {
+		v.add("Naming.Template", fmt.Sprintf("makes %q, which is not an identifier of RDS", identifier))
+	} else if len(identifier)-len(base) >= maxLength {
+		// Longer bases are shortened to fit, but the rest of the identifier is not.
+		v.add("Naming.Template", fmt.Sprintf("makes %q, which cannot be shortened to %d characters", identifier, maxLength))
+	}
+}
+
+// dns checks the section of the DNS provider which the config chooses.
+func (v *validator) dns(c *Config) {
+	var section string
+	switch c.DNSProvider() {
+	case DNSProviderRoute53:
+		section = "Route53"
+	case DNSProviderCloudflare:
+		section = "Cloudflare"
+		// The zone is looked up by Domain if ZoneID is empty.
+	case DNSProviderRFC2136:
+		section = "RFC2136"
+		if c.RFC2136.TSIGSecret != "" {
+			v.required("RFC2136.TSIGKeyName", c.RFC2136.TSIGKeyName)
+		}
+	default:
+		section = "DNSimple"
+		v.required("DNSimple.AuthToken", c.DNSimple.AuthToken)
+		v.required("DNSimple.AccountID", c.DNSimple.AccountID)
+	}
+
+	record := c.DNSRecord()
+	v.required(section+".Domain", record.Domain)
+	switch record.Type {
+	case dns.TypeCNAME:
+		// A CNAME record cannot be at the apex of the domain.
+		v.required(section+".RecordName", record.Name)
+	case dns.TypeA, dns.TypeAAAA:
+	default:
+		v.add(section+".RecordType", fmt.Sprintf("%q is not one of %s, %s and %s", record.Type, dns.TypeA, dns.TypeAAAA, dns.TypeCNAME))
+	}
+	if !(record.TTL >= minTTL && record.TTL <= maxTTL) && !(section == "Cloudflare" && record.TTL == 1) {
+		v.add(section+".TTL", fmt.Sprintf("%d is out of the range from %d to %d", record.TTL, minTTL, maxTTL))
+	}
+}
+
+// fieldTypes returns the types of the fields of the struct by their keys in YAML.
+func fieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name != "" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// unknownFields returns the keys of the YAML document which are not fields of the config.
+func unknownFields(doc interface{}) []*FieldError {
+	v := &validator{}
+	v.unknownFields(doc, reflect.TypeOf(Config{}), "")
+	return v.errors
+}
+
+func (v *validator) unknownFields(node interface{}, t reflect.Type, path string) {
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		fields := fieldTypes(t)
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := path + key
+			if ft, ok := fields[key]; ok {
+				v.unknownFields(m[key], ft, field+".")
+			} else {
+				v.add(field, "is not a known field")
+			}
+		}
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			v.unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i))
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package database
 
+// MaxIdentifierLength is the maximum length of the identifiers of RDS Instances and Aurora Clusters.
+const MaxIdentifierLength = 63
+
+// ClusterInstanceSuffix is the suffix of the RDS Instance added to an Aurora Cluster.
+const ClusterInstanceSuffix = "-001"
+
 type DBInstance struct {
 	URL      string
 	Port     int64
@@ -0,0 +1,299 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// CrossAccountConfig describes how a snapshot of the source in another account or region
+// is brought into the account and the region which the package manages.
+type CrossAccountConfig struct {
+	// Source manages RDS in the account and the region of the source.
+	Source *Client
+	// SourceIdentifier is the identifier of the source RDS Instance or Aurora Cluster.
+	SourceIdentifier string
+	// SnapshotIdentifier, or SnapshotPrefix and SnapshotTags, select an existing snapshot of the source.
+	// A new manual snapshot of the source is created if they are empty.
+	SnapshotIdentifier string
+	SnapshotPrefix     string
+	SnapshotTags       map[string]string
+	// SourceKMSKeyID re-encrypts the snapshot with a key which the target account is allowed to use
+	// before it is shared. Snapshots encrypted with the default key cannot be shared.
+	SourceKMSKeyID string
+	// TargetAccountID is the account which the snapshot is shared with.
+	TargetAccountID string
+	// TargetKMSKeyID re-encrypts the copy in the target account.
+	TargetKMSKeyID string
+	// TargetSnapshotIdentifier is the identifier of the copy in the target account.
+	TargetSnapshotIdentifier string
+	Tags                     map[string]string
+}
+
+// CopyDBSnapshotAcrossAccounts shares a snapshot of the source RDS Instance with the target account
+// and copies it there. It returns the identifier of the copy, which can be restored.
+func (c *Client) CopyDBSnapshotAcrossAccounts(ctx context.Context, config *CrossAccountConfig) (_ string, err error) {
+	defer wrapError(&err, "copy a snapshot of", config.SourceIdentifier)
+	return c.copyAcrossAccounts(ctx, dbSnapshots, config)
+}
+
+// CopyDBClusterSnapshotAcrossAccounts shares a snapshot of the source Aurora Cluster with the target account
+// and copies it there. It returns the identifier of the copy, which can be restored.
+func (c *Client) CopyDBClusterSnapshotAcrossAccounts(ctx context.Context, config *CrossAccountConfig) (_ string, err error) {
+	defer wrapError(&err, "copy a snapshot of", config.SourceIdentifier)
+	return c.copyAcrossAccounts(ctx, dbClusterSnapshots, config)
+}
+
+// snapshotInfo is what copyAcrossAccounts needs to know of a snapshot of either kind.
+type snapshotInfo struct {
+	ARN    string
+	Type   string
+	Status string
+}
+
+// snapshotKind has the operations on the snapshots of RDS Instances or of Aurora Clusters,
+// which only differ in the types of the SDK.
+type snapshotKind struct {
+	name string
+	// describe returns the snapshot, or nil if it does not exist.
+	describe func(c *Client, ctx context.Context, identifier string) (*snapshotInfo, error)
+	find     func(c *Client, ctx context.Context, sourceIdentifier, prefix string, tags map[string]string) (string, error)
+	create   func(c *Client, ctx context.Context, sourceIdentifier, identifier string, tags map[string]string) error
+	// copy copies the snapshot, whose ARN is given if it is in another account, with the KMS key if any.
+	copy   func(c *Client, ctx context.Context, source, identifier, kmsKeyID, sourceRegion string, tags map[string]string) error
+	share  func(c *Client, ctx context.Context, identifier, accountID string) error
+	wait   func(c *Client, ctx context.Context, identifier string) error
+	delete func(c *Client, ctx context.Context, identifier string) error
+}
+
+var dbSnapshots = &snapshotKind{
+	name: "RDS snapshot",
+	describe: func(c *Client, ctx context.Context, identifier string) (*snapshotInfo, error) {
+		snapshot, err := c.dbSnapshot(ctx, identifier)
+		if err != nil || snapshot == nil {
+			return nil, err
+		}
+		return &snapshotInfo{
+			ARN:    aws.StringValue(snapshot.DBSnapshotArn),
+			Type:   aws.StringValue(snapshot.SnapshotType),
+			Status: aws.StringValue(snapshot.Status),
+		}, nil
+	},
+	find: (*Client).FindDBSnapshot,
+	create: func(c *Client, ctx context.Context, sourceIdentifier, identifier string, t map[string]string) error {
+		_, err := c.rds.CreateDBSnapshotWithContext(ctx, &rds.CreateDBSnapshotInput{
+			DBInstanceIdentifier: aws.String(sourceIdentifier),
+			DBSnapshotIdentifier: aws.String(identifier),
+			Tags:                 tags(t),
+		})
+		return err
+	},
+	copy: func(c *Client, ctx context.Context, source, identifier, kmsKeyID, sourceRegion string, t map[string]string) error {
+		input := &rds.CopyDBSnapshotInput{
+			SourceDBSnapshotIdentifier: aws.String(source),
+			TargetDBSnapshotIdentifier: aws.String(identifier),
+			Tags:                       tags(t),
+		}
+		if kmsKeyID != "" {
+			input.KmsKeyId = aws.String(kmsKeyID)
+		}
+		if sourceRegion != "" {
+			input.SourceRegion = aws.String(sourceRegion)
+		}
+		_, err := c.rds.CopyDBSnapshotWithContext(ctx, input)
+		return err
+	},
+	share: func(c *Client, ctx context.Context, identifier, accountID string) error {
+		_, err := c.rds.ModifyDBSnapshotAttributeWithContext(ctx, &rds.ModifyDBSnapshotAttributeInput{
+			DBSnapshotIdentifier: aws.String(identifier),
+			AttributeName:        aws.String("restore"),
+			ValuesToAdd:          []*string{aws.String(accountID)},
+		})
+		return err
+	},
+	wait:   (*Client).waitUntilDBSnapshotAvailable,
+	delete: (*Client).DeleteDBSnapshot,
+}
+
+var dbClusterSnapshots = &snapshotKind{
+	name: "Aurora Cluster snapshot",
+	describe: func(c *Client, ctx context.Context, identifier string) (*snapshotInfo, error) {
+		snapshot, err := c.dbClusterSnapshot(ctx, identifier)
+		if err != nil || snapshot == nil {
+			return nil, err
+		}
+		return &snapshotInfo{
+			ARN:    aws.StringValue(snapshot.DBClusterSnapshotArn),
+			Type:   aws.StringValue(snapshot.SnapshotType),
+			Status: aws.StringValue(snapshot.Status),
+		}, nil
+	},
+	find: (*Client).FindDBClusterSnapshot,
+	create: func(c *Client, ctx context.Context, sourceIdentifier, identifier string, t map[string]string) error {
+		_, err := c.rds.CreateDBClusterSnapshotWithContext(ctx, &rds.CreateDBClusterSnapshotInput{
+			DBClusterIdentifier:         aws.String(sourceIdentifier),
+			DBClusterSnapshotIdentifier: aws.String(identifier),
+			Tags:                        tags(t),
+		})
+		return err
+	},
+	copy: func(c *Client, ctx context.Context, source, identifier, kmsKeyID, sourceRegion string, t map[string]string) error {
+		input := &rds.CopyDBClusterSnapshotInput{
+			SourceDBClusterSnapshotIdentifier: aws.String(source),
+			TargetDBClusterSnapshotIdentifier: aws.String(identifier),
+			Tags:                              tags(t),
+		}
+		if kmsKeyID != "" {
+			input.KmsKeyId = aws.String(kmsKeyID)
+		}
+		if sourceRegion != "" {
+			input.SourceRegion = aws.String(sourceRegion)
+		}
+		_, err := c.rds.CopyDBClusterSnapshotWithContext(ctx, input)
+		return err
+	},
+	share: func(c *Client, ctx context.Context, identifier, accountID string) error {
+		_, err := c.rds.ModifyDBClusterSnapshotAttributeWithContext(ctx, &rds.ModifyDBClusterSnapshotAttributeInput{
+			DBClusterSnapshotIdentifier: aws.String(identifier),
+			AttributeName:               aws.String("restore"),
+			ValuesToAdd:                 []*string{aws.String(accountID)},
+		})
+		return err
+	},
+	wait:   (*Client).waitUntilDBClusterSnapshotAvailable,
+	delete: (*Client).DeleteDBClusterSnapshot,
+}
+
+// copyAcrossAccounts shares a snapshot of the source with the target account of c and copies it there.
+// The temporary snapshots in the source account are named after the copy, so that a run which was
+// interrupted is resumed with the ones which it created.
+func (c *Client) copyAcrossAccounts(ctx context.Context, kind *snapshotKind, config *CrossAccountConfig) (_ string, err error) {
+	src := config.Source
+	dst := c
+	sourceIdentifier := config.TargetSnapshotIdentifier + "-source"
+	sharedIdentifier := config.TargetSnapshotIdentifier + "-shared"
+
+	// The temporary snapshots are deleted once the copy in the target account is available, or when the run
+	// fails before the copy has started. The source of a copy in progress is kept for the resumed run.
+	copying := false
+	defer func() {
+		if err != nil && copying {
+			return
+		}
+		for _, identifier := range []string{sourceIdentifier, sharedIdentifier} {
+			src.deleteTemporarySnapshot(kind, identifier)
+		}
+	}()
+
+	if snapshot, err := kind.describe(dst, ctx, config.TargetSnapshotIdentifier); err != nil {
+		return "", err
+	} else if snapshot != nil {
+		log.Printf("%s %s is already exists\n", kind.name, config.TargetSnapshotIdentifier)
+		copying = true
+		return config.TargetSnapshotIdentifier, kind.wait(dst, ctx, config.TargetSnapshotIdentifier)
+	}
+
+	snapshotIdentifier := config.SnapshotIdentifier
+	if snapshotIdentifier == "" && (config.SnapshotPrefix != "" || len(config.SnapshotTags) != 0) {
+		identifier, err := kind.find(src, ctx, config.SourceIdentifier, config.SnapshotPrefix, config.SnapshotTags)
+		if err != nil {
+			return "", err
+		}
+		snapshotIdentifier = identifier
+	}
+	if snapshotIdentifier == "" {
+		snapshotIdentifier = sourceIdentifier
+		if existing, err := kind.describe(src, ctx, snapshotIdentifier); err != nil {
+			return "", err
+		} else if existing != nil {
+			log.Printf("%s %s is already exists\n", kind.name, snapshotIdentifier)
+		} else {
+			if err := kind.create(src, ctx, config.SourceIdentifier, snapshotIdentifier, config.Tags); err != nil {
+				return "", err
+			}
+			log.Printf("created %s %s of %s\n", kind.name, snapshotIdentifier, config.SourceIdentifier)
+		}
+		if err := kind.wait(src, ctx, snapshotIdentifier); err != nil {
+			return "", err
+		}
+	}
+
+	snapshot, err := kind.describe(src, ctx, snapshotIdentifier)
+	if err != nil {
+		return "", err
+	} else if snapshot == nil {
+		return "", fmt.Errorf("%s %s is not found in the source account", kind.name, snapshotIdentifier)
+	}
+
+	// Automated snapshots cannot be shared, so they are copied into manual ones first.
+	if config.SourceKMSKeyID != "" || snapshot.Type == "automated" {
+		if existing, err := kind.describe(src, ctx, sharedIdentifier); err != nil {
+			return "", err
+		} else if existing != nil {
+			log.Printf("%s %s is already exists\n", kind.name, sharedIdentifier)
+		} else {
+			if err := kind.copy(src, ctx, snapshotIdentifier, sharedIdentifier, config.SourceKMSKeyID, "", config.Tags); err != nil {
+				return "", err
+			}
+			log.Printf("copied %s %s to %s in the source account\n", kind.name, snapshotIdentifier, sharedIdentifier)
+		}
+		if err := kind.wait(src, ctx, sharedIdentifier); err != nil {
+			return "", err
+		}
+
+		if snapshot, err = kind.describe(src, ctx, sharedIdentifier); err != nil {
+			return "", err
+		} else if snapshot == nil {
+			return "", fmt.Errorf("%s %s is not found in the source account", kind.name, sharedIdentifier)
+		}
+		snapshotIdentifier = sharedIdentifier
+	}
+
+	if err := kind.share(src, ctx, snapshotIdentifier, config.TargetAccountID); err != nil {
+		return "", err
+	}
+	log.Printf("shared %s %s with account %s\n", kind.name, snapshotIdentifier, config.TargetAccountID)
+
+	sourceRegion := ""
+	if region := aws.StringValue(src.rds.Config.Region); region != aws.StringValue(dst.rds.Config.Region) {
+		sourceRegion = region
+	}
+	if err := kind.copy(dst, ctx, snapshot.ARN, config.TargetSnapshotIdentifier, config.TargetKMSKeyID, sourceRegion, config.Tags); err != nil {
+		return "", err
+	}
+	copying = true
+	log.Printf("copied %s %s to %s in the target account\n", kind.name, snapshotIdentifier, config.TargetSnapshotIdentifier)
+
+	if err := kind.wait(dst, ctx, config.TargetSnapshotIdentifier); err != nil {
+		return "", err
+	}
+
+	return config.TargetSnapshotIdentifier, nil
+}
+
+// deleteTemporarySnapshot deletes the temporary snapshot if it exists and is available. RDS refuses to delete
+// a snapshot which is still being created, so it is left for the resumed run, which reuses it.
+// It runs even after the context of the run is canceled.
+func (c *Client) deleteTemporarySnapshot(kind *snapshotKind, identifier string) {
+	ctx := context.Background()
+	snapshot, err := kind.describe(c, ctx, identifier)
+	if err != nil {
+		log.Printf("failed to get informations of the temporary %s %s: %s\n", kind.name, identifier, err)
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+	if snapshot.Status != snapshotStatusAvailable {
+		log.Printf("left the temporary %s %s, which is %s, for rotate -resume\n", kind.name, identifier, snapshot.Status)
+		return
+	}
+	if err := kind.delete(c, ctx, identifier); err != nil {
+		log.Printf("failed to delete the temporary %s %s: %s\n", kind.name, identifier, err)
+		return
+	}
+	log.Printf("deleted the temporary %s %s\n", kind.name, identifier)
+}
@@ -0,0 +1,67 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// ErrNotReady is returned when a resource does not become available within the wait attempts.
+var ErrNotReady = errors.New("not ready, exceed max wait attempts")
+
+// transientCodes are the error codes of RDS which a later retry may not get,
+// such as the ones returned while a resource is being modified.
+var transientCodes = map[string]bool{
+	rds.ErrCodeInvalidDBInstanceStateFault:         true,
+	rds.ErrCodeInvalidDBClusterStateFault:          true,
+	rds.ErrCodeInvalidDBSnapshotStateFault:         true,
+	rds.ErrCodeInvalidDBClusterSnapshotStateFault:  true,
+	rds.ErrCodeInsufficientDBInstanceCapacityFault: true,
+}
+
+// Error is a failure of an operation of a Client.
+type Error struct {
+	// Op is the operation, e.g. "restore".
+	Op string
+	// Identifier is the identifier of the RDS Instance, the Aurora Cluster or the snapshot, if any.
+	Identifier string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Identifier == "" {
+		return fmt.Sprintf("%s: %s", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Op, e.Identifier, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether a retry of the operation may succeed, such as after throttling
+// or while the resource is busy.
+func (e *Error) Temporary() bool {
+	if errors.Is(e.Err, ErrNotReady) {
+		return true
+	}
+	var aerr awserr.Error
+	if !errors.As(e.Err, &aerr) {
+		return false
+	}
+	return transientCodes[aerr.Code()] || request.IsErrorThrottle(aerr) || request.IsErrorRetryable(aerr)
+}
+
+// wrapError replaces the error, if any, with an *Error of the operation unless it already is one.
+func wrapError(err *error, op, identifier string) {
+	if *err == nil {
+		return
+	}
+	if _, ok := (*err).(*Error); ok {
+		return
+	}
+	*err = &Error{Op: op, Identifier: identifier, Err: *err}
+}
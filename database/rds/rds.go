@@ -1,27 +1,28 @@
 package rds
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/rds"
-	awspkg "github.com/munisystem/rosculus/aws"
 	"github.com/munisystem/rosculus/database"
 )
 
-var (
-	rdscli *rds.RDS
-)
+// Client manages RDS in the account and the region of its session.
+type Client struct {
+	rds *rds.RDS
+}
 
-func client() *rds.RDS {
-	if rdscli == nil {
-		rdscli = rds.New(awspkg.Session())
-	}
-	return rdscli
+// New returns a Client which uses the session.
+func New(sess *session.Session) *Client {
+	return &Client{rds: rds.New(sess)}
 }
 
 func tags(tags map[string]string) []*rds.Tag {
@@ -50,130 +51,151 @@ type DBInstanceConfig struct {
 	VpcSecurityGroupIds        []string
 	Tags                       map[string]string
 	MasterUserPassword         string
+	// SnapshotIdentifier restores the RDS Instance from the snapshot instead of the source.
+	SnapshotIdentifier string
+	// RestoreTime restores the source to the point in time instead of the latest restorable time.
+	RestoreTime *time.Time
 }
 
-func CloneDBInstance(config *DBInstanceConfig) (*database.DBInstance, error) {
-	cli := client()
+type DBClusterConfig struct {
+	SourceDBClusterIdentifier string
+	DBClusterIdentifier       string
+	AvailabilityZone          string
+	PubliclyAccessible        bool
+	DBInstanceClass           string
+	DBSubnetGroupName         string
+	VpcSecurityGroupIds       []string
+	Tags                      map[string]string
+	MasterUserPassword        string
+	// SnapshotIdentifier restores the Aurora Cluster from the snapshot instead of the source.
+	SnapshotIdentifier string
+	// RestoreTime restores the source to the point in time instead of the latest restorable time.
+	RestoreTime *time.Time
+}
 
-	if instance, err := dbInstance(config.TargetDBInstanceIdentifier); err != nil {
-		return nil, err
+// ClusterInstanceIdentifier returns the identifier of the RDS Instance added to the Aurora Cluster.
+func ClusterInstanceIdentifier(dbClusterIdentifier string) string {
+	return dbClusterIdentifier + database.ClusterInstanceSuffix
+}
+
+// RestoreDBInstance restores the RDS Instance unless it already exists, and waits until it is available.
+func (c *Client) RestoreDBInstance(ctx context.Context, config *DBInstanceConfig) (err error) {
+	defer wrapError(&err, "restore", config.TargetDBInstanceIdentifier)
+
+	if instance, err := c.dbInstance(ctx, config.TargetDBInstanceIdentifier); err != nil {
+		return err
 	} else if instance == nil {
-		input := &rds.RestoreDBInstanceToPointInTimeInput{
-			SourceDBInstanceIdentifier: aws.String(config.SourceDBInstanceIdentifier),
-			TargetDBInstanceIdentifier: aws.String(config.TargetDBInstanceIdentifier),
-			AvailabilityZone:           aws.String(config.AvailabilityZone),
-			PubliclyAccessible:         aws.Bool(config.PubliclyAccessible),
-			DBInstanceClass:            aws.String(config.DBInstanceClass),
-			DBSubnetGroupName:          aws.String(config.DBSubnetGroupName),
-			UseLatestRestorableTime:    aws.Bool(true),
-			VpcSecurityGroupIds:        vpcSecurityGroupIds(config.VpcSecurityGroupIds),
-			Tags:                       tags(config.Tags),
-		}
-		if _, err := cli.RestoreDBInstanceToPointInTime(input); err != nil {
-			return nil, err
+		if err := c.restoreDBInstance(ctx, config); err != nil {
+			return err
 		}
 		log.Printf("created RDS Instance %s\n", config.TargetDBInstanceIdentifier)
 	} else {
 		log.Printf("RDS Instance %s is already exists\n", config.TargetDBInstanceIdentifier)
 	}
 
-	if err := waitUntilDBInstanceAvailable(config.TargetDBInstanceIdentifier); err != nil {
-		return nil, err
-	}
+	return c.waitUntilDBInstanceAvailable(ctx, config.TargetDBInstanceIdentifier)
+}
 
-	if err := modifyDBInstance(config); err != nil {
-		return nil, err
+func (c *Client) restoreDBInstance(ctx context.Context, config *DBInstanceConfig) error {
+	if config.SnapshotIdentifier != "" {
+		input := &rds.RestoreDBInstanceFromDBSnapshotInput{
+			DBSnapshotIdentifier: aws.String(config.SnapshotIdentifier),
+			DBInstanceIdentifier: aws.String(config.TargetDBInstanceIdentifier),
+			AvailabilityZone:     aws.String(config.AvailabilityZone),
+			PubliclyAccessible:   aws.Bool(config.PubliclyAccessible),
+			DBInstanceClass:      aws.String(config.DBInstanceClass),
+			DBSubnetGroupName:    aws.String(config.DBSubnetGroupName),
+			VpcSecurityGroupIds:  vpcSecurityGroupIds(config.VpcSecurityGroupIds),
+			Tags:                 tags(config.Tags),
+		}
+		_, err := c.rds.RestoreDBInstanceFromDBSnapshotWithContext(ctx, input)
+		return err
 	}
 
-	instance, err := dbInstance(config.TargetDBInstanceIdentifier)
-	if err != nil {
-		return nil, err
-	} else if instance == nil {
-		return nil, fmt.Errorf("failed to get informations of RDS Instance %s", config.TargetDBInstanceIdentifier)
+	input := &rds.RestoreDBInstanceToPointInTimeInput{
+		SourceDBInstanceIdentifier: aws.String(config.SourceDBInstanceIdentifier),
+		TargetDBInstanceIdentifier: aws.String(config.TargetDBInstanceIdentifier),
+		AvailabilityZone:           aws.String(config.AvailabilityZone),
+		PubliclyAccessible:         aws.Bool(config.PubliclyAccessible),
+		DBInstanceClass:            aws.String(config.DBInstanceClass),
+		DBSubnetGroupName:          aws.String(config.DBSubnetGroupName),
+		VpcSecurityGroupIds:        vpcSecurityGroupIds(config.VpcSecurityGroupIds),
+		Tags:                       tags(config.Tags),
 	}
-
-	return &database.DBInstance{
-		URL:      *instance.Endpoint.Address,
-		Port:     *instance.Endpoint.Port,
-		Database: *instance.DBName,
-		User:     *instance.MasterUsername,
-		Password: config.MasterUserPassword,
-	}, nil
-}
-
-type DBClusterConfig struct {
-	SourceDBClusterIdentifier string
-	DBClusterIdentifier       string
-	AvailabilityZone          string
-	PubliclyAccessible        bool
-	DBInstanceClass           string
-	DBSubnetGroupName         string
-	VpcSecurityGroupIds       []string
-	Tags                      map[string]string
-	MasterUserPassword        string
+	if config.RestoreTime != nil {
+		input.RestoreTime = config.RestoreTime
+	} else {
+		input.UseLatestRestorableTime = aws.Bool(true)
+	}
+	_, err := c.rds.RestoreDBInstanceToPointInTimeWithContext(ctx, input)
+	return err
 }
 
-func CloneDBCluster(config *DBClusterConfig) (*database.DBInstance, error) {
-	cli := client()
+// RestoreDBCluster restores the Aurora Cluster unless it already exists, and waits until it is available.
+func (c *Client) RestoreDBCluster(ctx context.Context, config *DBClusterConfig) (err error) {
+	defer wrapError(&err, "restore", config.DBClusterIdentifier)
 
-	if cluster, err := dbCluster(config.DBClusterIdentifier); err != nil {
-		return nil, err
+	if cluster, err := c.dbCluster(ctx, config.DBClusterIdentifier); err != nil {
+		return err
 	} else if cluster == nil {
-		input := &rds.RestoreDBClusterToPointInTimeInput{
-			SourceDBClusterIdentifier: aws.String(config.SourceDBClusterIdentifier),
-			DBClusterIdentifier:       aws.String(config.DBClusterIdentifier),
-			DBSubnetGroupName:         aws.String(config.DBSubnetGroupName),
-			UseLatestRestorableTime:   aws.Bool(true),
-			VpcSecurityGroupIds:       vpcSecurityGroupIds(config.VpcSecurityGroupIds),
-			Tags:                      tags(config.Tags),
-		}
-		if _, err := cli.RestoreDBClusterToPointInTime(input); err != nil {
-			return nil, err
+		if err := c.restoreDBCluster(ctx, config); err != nil {
+			return err
 		}
 		log.Printf("created Aurora Cluster %s\n", config.DBClusterIdentifier)
 	} else {
 		log.Printf("Aurora Cluster %s is already exists\n", config.DBClusterIdentifier)
 	}
 
-	if err := waitUntilDBClusterAvailable(config.DBClusterIdentifier); err != nil {
-		return nil, err
-	}
+	return c.waitUntilDBClusterAvailable(ctx, config.DBClusterIdentifier)
+}
 
-	if err := modifyDBCluster(config); err != nil {
-		return nil, err
-	}
+func (c *Client) restoreDBCluster(ctx context.Context, config *DBClusterConfig) error {
+	if config.SnapshotIdentifier != "" {
+		snapshot, err := c.dbClusterSnapshot(ctx, config.SnapshotIdentifier)
+		if err != nil {
+			return err
+		} else if snapshot == nil {
+			return fmt.Errorf("Aurora Cluster snapshot %s is not found", config.SnapshotIdentifier)
+		}
 
-	if err := addDBInstanceToCluster(config); err != nil {
-		return nil, err
+		input := &rds.RestoreDBClusterFromSnapshotInput{
+			SnapshotIdentifier:  aws.String(config.SnapshotIdentifier),
+			DBClusterIdentifier: aws.String(config.DBClusterIdentifier),
+			Engine:              snapshot.Engine,
+			EngineVersion:       snapshot.EngineVersion,
+			DBSubnetGroupName:   aws.String(config.DBSubnetGroupName),
+			VpcSecurityGroupIds: vpcSecurityGroupIds(config.VpcSecurityGroupIds),
+			Tags:                tags(config.Tags),
+		}
+		_, err = c.rds.RestoreDBClusterFromSnapshotWithContext(ctx, input)
+		return err
 	}
 
-	cluster, err := dbCluster(config.DBClusterIdentifier)
-	if err != nil {
-		return nil, err
-	} else if cluster == nil {
-		return nil, fmt.Errorf("failed to get informations of Aurora cluster %s", config.DBClusterIdentifier)
+	input := &rds.RestoreDBClusterToPointInTimeInput{
+		SourceDBClusterIdentifier: aws.String(config.SourceDBClusterIdentifier),
+		DBClusterIdentifier:       aws.String(config.DBClusterIdentifier),
+		DBSubnetGroupName:         aws.String(config.DBSubnetGroupName),
+		VpcSecurityGroupIds:       vpcSecurityGroupIds(config.VpcSecurityGroupIds),
+		Tags:                      tags(config.Tags),
 	}
-
-	return &database.DBInstance{
-		URL:      *cluster.Endpoint,
-		Port:     *cluster.Port,
-		Database: *cluster.DatabaseName,
-		User:     *cluster.MasterUsername,
-		Password: config.MasterUserPassword,
-	}, nil
+	if config.RestoreTime != nil {
+		input.RestoreToTime = config.RestoreTime
+	} else {
+		input.UseLatestRestorableTime = aws.Bool(true)
+	}
+	_, err := c.rds.RestoreDBClusterToPointInTimeWithContext(ctx, input)
+	return err
 }
 
-func addDBInstanceToCluster(config *DBClusterConfig) error {
-	cli := client()
+// AddDBInstanceToCluster creates the RDS Instance in the Aurora Cluster unless it already exists,
+// and waits until it is available.
+func (c *Client) AddDBInstanceToCluster(ctx context.Context, config *DBClusterConfig) (err error) {
+	defer wrapError(&err, "add an RDS Instance to", config.DBClusterIdentifier)
 
-	instanceIdentifier := config.DBClusterIdentifier + "-001"
+	instanceIdentifier := ClusterInstanceIdentifier(config.DBClusterIdentifier)
 
-	var (
-		instance *rds.DBInstance
-		err      error
-	)
-
-	if instance, err = dbInstance(instanceIdentifier); err != nil {
+	var instance *rds.DBInstance
+	if instance, err = c.dbInstance(ctx, instanceIdentifier); err != nil {
 		return err
 	} else if instance == nil {
 		input := &rds.CreateDBInstanceInput{
@@ -185,7 +207,7 @@ func addDBInstanceToCluster(config *DBClusterConfig) error {
 			Engine:               aws.String("aurora-postgresql"),
 			Tags:                 tags(config.Tags),
 		}
-		resp, err := cli.CreateDBInstance(input)
+		resp, err := c.rds.CreateDBInstanceWithContext(ctx, input)
 		if err != nil {
 			return err
 		}
@@ -195,16 +217,19 @@ func addDBInstanceToCluster(config *DBClusterConfig) error {
 		log.Printf("RDS Instance %s is already exists in Aurora Cluster %s\n", instanceIdentifier, config.DBClusterIdentifier)
 	}
 
-	if err := waitUntilDBInstanceAvailable(instanceIdentifier); err != nil {
+	if err := c.waitUntilDBInstanceAvailable(ctx, instanceIdentifier); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func modifyDBInstance(config *DBInstanceConfig) error {
+// ModifyDBInstance applies the instance class, the security groups and the master user password
+// to the RDS Instance, and waits until it is available.
+func (c *Client) ModifyDBInstance(ctx context.Context, config *DBInstanceConfig) (err error) {
+	defer wrapError(&err, "modify", config.TargetDBInstanceIdentifier)
+
 	log.Printf("modify RDS Instance %s\n", config.TargetDBInstanceIdentifier)
-	cli := client()
 
 	input := &rds.ModifyDBInstanceInput{
 		DBInstanceIdentifier: aws.String(config.TargetDBInstanceIdentifier),
@@ -215,17 +240,20 @@ func modifyDBInstance(config *DBInstanceConfig) error {
 		ApplyImmediately:     aws.Bool(true),
 	}
 
-	if _, err := cli.ModifyDBInstance(input); err != nil {
+	if _, err := c.rds.ModifyDBInstanceWithContext(ctx, input); err != nil {
 		return err
 	}
 	log.Printf("modified RDS Instance %s\n", config.TargetDBInstanceIdentifier)
 
-	return waitUntilDBInstanceAvailable(config.TargetDBInstanceIdentifier)
+	return c.waitUntilDBInstanceAvailable(ctx, config.TargetDBInstanceIdentifier)
 }
 
-func modifyDBCluster(config *DBClusterConfig) error {
+// ModifyDBCluster applies the security groups and the master user password to the Aurora Cluster,
+// and waits until it is available.
+func (c *Client) ModifyDBCluster(ctx context.Context, config *DBClusterConfig) (err error) {
+	defer wrapError(&err, "modify", config.DBClusterIdentifier)
+
 	log.Printf("modify Aurora Cluster %s\n", config.DBClusterIdentifier)
-	cli := client()
 
 	input := &rds.ModifyDBClusterInput{
 		DBClusterIdentifier: aws.String(config.DBClusterIdentifier),
@@ -234,22 +262,56 @@ func modifyDBCluster(config *DBClusterConfig) error {
 		ApplyImmediately:    aws.Bool(true),
 	}
 
-	if _, err := cli.ModifyDBCluster(input); err != nil {
+	if _, err := c.rds.ModifyDBClusterWithContext(ctx, input); err != nil {
 		return err
 	}
 	log.Printf("modified Aurora Cluster %s\n", config.DBClusterIdentifier)
 
-	return waitUntilDBClusterAvailable(config.DBClusterIdentifier)
+	return c.waitUntilDBClusterAvailable(ctx, config.DBClusterIdentifier)
+}
+
+// ModifyDBInstancePassword sets the master user password of the RDS Instance, and waits until it is available.
+func (c *Client) ModifyDBInstancePassword(ctx context.Context, dbInstanceIdentifier, password string) (err error) {
+	defer wrapError(&err, "modify", dbInstanceIdentifier)
+
+	input := &rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+		MasterUserPassword:   aws.String(password),
+		ApplyImmediately:     aws.Bool(true),
+	}
+	if _, err := c.rds.ModifyDBInstanceWithContext(ctx, input); err != nil {
+		return err
+	}
+	log.Printf("set the master user password of RDS Instance %s\n", dbInstanceIdentifier)
+
+	return c.waitUntilDBInstanceAvailable(ctx, dbInstanceIdentifier)
+}
+
+// ModifyDBClusterPassword sets the master user password of the Aurora Cluster, and waits until it is available.
+func (c *Client) ModifyDBClusterPassword(ctx context.Context, dbClusterIdentifier, password string) (err error) {
+	defer wrapError(&err, "modify", dbClusterIdentifier)
+
+	input := &rds.ModifyDBClusterInput{
+		DBClusterIdentifier: aws.String(dbClusterIdentifier),
+		MasterUserPassword:  aws.String(password),
+		ApplyImmediately:    aws.Bool(true),
+	}
+	if _, err := c.rds.ModifyDBClusterWithContext(ctx, input); err != nil {
+		return err
+	}
+	log.Printf("set the master user password of Aurora Cluster %s\n", dbClusterIdentifier)
+
+	return c.waitUntilDBClusterAvailable(ctx, dbClusterIdentifier)
 }
 
-func DeleteDBInstance(dbInstanceIdentifier string) error {
-	cli := client()
+func (c *Client) DeleteDBInstance(ctx context.Context, dbInstanceIdentifier string) (err error) {
+	defer wrapError(&err, "delete", dbInstanceIdentifier)
 
 	input := &rds.DeleteDBInstanceInput{
 		DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
 		SkipFinalSnapshot:    aws.Bool(true),
 	}
-	if _, err := cli.DeleteDBInstance(input); err != nil {
+	if _, err := c.rds.DeleteDBInstanceWithContext(ctx, input); err != nil {
 		if err != nil {
 			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBInstanceNotFoundFault {
 				return nil
@@ -261,15 +323,15 @@ func DeleteDBInstance(dbInstanceIdentifier string) error {
 	return nil
 }
 
-func DeleteDBCluster(dbClusterIdentifier string) error {
-	cli := client()
+func (c *Client) DeleteDBCluster(ctx context.Context, dbClusterIdentifier string) (err error) {
+	defer wrapError(&err, "delete", dbClusterIdentifier)
 
 	input := &rds.DeleteDBClusterInput{
 		DBClusterIdentifier: aws.String(dbClusterIdentifier),
 		SkipFinalSnapshot:   aws.Bool(true),
 	}
 
-	resp, err := cli.DescribeDBClusters(&rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(dbClusterIdentifier)})
+	resp, err := c.rds.DescribeDBClustersWithContext(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(dbClusterIdentifier)})
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBClusterNotFoundFault {
 			return nil
@@ -277,11 +339,11 @@ func DeleteDBCluster(dbClusterIdentifier string) error {
 		return err
 	}
 	for _, member := range resp.DBClusters[0].DBClusterMembers {
-		if err := DeleteDBInstance(*member.DBInstanceIdentifier); err != nil {
+		if err := c.DeleteDBInstance(ctx, *member.DBInstanceIdentifier); err != nil {
 			return err
 		}
 	}
-	if _, err := cli.DeleteDBCluster(input); err != nil {
+	if _, err := c.rds.DeleteDBClusterWithContext(ctx, input); err != nil {
 		if err != nil {
 			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBClusterNotFoundFault {
 				return nil
@@ -293,12 +355,137 @@ func DeleteDBCluster(dbClusterIdentifier string) error {
 	return nil
 }
 
-func waitUntilDBInstanceAvailable(dbInstanceIdentifier string) error {
+// DescribeDBInstance returns the connection information of the RDS Instance.
+// It returns nil if the RDS Instance does not exist.
+func (c *Client) DescribeDBInstance(ctx context.Context, dbInstanceIdentifier string) (_ *database.DBInstance, err error) {
+	defer wrapError(&err, "describe", dbInstanceIdentifier)
+
+	instance, err := c.dbInstance(ctx, dbInstanceIdentifier)
+	if err != nil || instance == nil {
+		return nil, err
+	}
+
+	db := &database.DBInstance{
+		Database: aws.StringValue(instance.DBName),
+		User:     aws.StringValue(instance.MasterUsername),
+	}
+	// Endpoint is not assigned while the RDS Instance is being created.
+	if instance.Endpoint != nil {
+		db.URL = aws.StringValue(instance.Endpoint.Address)
+		db.Port = aws.Int64Value(instance.Endpoint.Port)
+	}
+
+	return db, nil
+}
+
+// DescribeDBCluster returns the connection information of the Aurora Cluster.
+// It returns nil if the Aurora Cluster does not exist.
+func (c *Client) DescribeDBCluster(ctx context.Context, dbClusterIdentifier string) (_ *database.DBInstance, err error) {
+	defer wrapError(&err, "describe", dbClusterIdentifier)
+
+	cluster, err := c.dbCluster(ctx, dbClusterIdentifier)
+	if err != nil || cluster == nil {
+		return nil, err
+	}
+
+	return &database.DBInstance{
+		URL:      aws.StringValue(cluster.Endpoint),
+		Port:     aws.Int64Value(cluster.Port),
+		Database: aws.StringValue(cluster.DatabaseName),
+		User:     aws.StringValue(cluster.MasterUsername),
+	}, nil
+}
+
+// DBResource describes an RDS Instance or an Aurora Cluster.
+type DBResource struct {
+	Identifier string
+	CreateTime time.Time
+	Tags       map[string]string
+}
+
+// tagMap returns the tags of RDS as a map from the keys to the values.
+func tagMap(rdsTags []*rds.Tag) map[string]string {
+	tags := make(map[string]string, len(rdsTags))
+	for _, tag := range rdsTags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags
+}
+
+// hasTags reports whether rdsTags contain every tag in tags.
+func hasTags(rdsTags []*rds.Tag, tags map[string]string) bool {
+	for key, value := range tags {
+		found := false
+		for _, tag := range rdsTags {
+			if aws.StringValue(tag.Key) == key && aws.StringValue(tag.Value) == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ListDBInstances returns the RDS Instances whose identifier starts with prefix and which have every tag in tags.
+func (c *Client) ListDBInstances(ctx context.Context, prefix string, tags map[string]string) (_ []*DBResource, err error) {
+	defer wrapError(&err, "list RDS Instances", "")
+
+	resources := []*DBResource{}
+	err = c.rds.DescribeDBInstancesPagesWithContext(ctx, &rds.DescribeDBInstancesInput{}, func(resp *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range resp.DBInstances {
+			identifier := aws.StringValue(instance.DBInstanceIdentifier)
+			if !strings.HasPrefix(identifier, prefix) || !hasTags(instance.TagList, tags) {
+				continue
+			}
+			resources = append(resources, &DBResource{
+				Identifier: identifier,
+				CreateTime: aws.TimeValue(instance.InstanceCreateTime),
+				Tags:       tagMap(instance.TagList),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// ListDBClusters returns the Aurora Clusters whose identifier starts with prefix and which have every tag in tags.
+func (c *Client) ListDBClusters(ctx context.Context, prefix string, tags map[string]string) (_ []*DBResource, err error) {
+	defer wrapError(&err, "list Aurora Clusters", "")
+
+	resources := []*DBResource{}
+	err = c.rds.DescribeDBClustersPagesWithContext(ctx, &rds.DescribeDBClustersInput{}, func(resp *rds.DescribeDBClustersOutput, lastPage bool) bool {
+		for _, cluster := range resp.DBClusters {
+			identifier := aws.StringValue(cluster.DBClusterIdentifier)
+			if !strings.HasPrefix(identifier, prefix) || !hasTags(cluster.TagList, tags) {
+				continue
+			}
+			resources = append(resources, &DBResource{
+				Identifier: identifier,
+				CreateTime: aws.TimeValue(cluster.ClusterCreateTime),
+				Tags:       tagMap(cluster.TagList),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+func (c *Client) waitUntilDBInstanceAvailable(ctx context.Context, dbInstanceIdentifier string) error {
 	log.Printf("wait until RDS Instance %s is ready\n", dbInstanceIdentifier)
-	cli := client()
 
 	for {
-		err := cli.WaitUntilDBInstanceAvailable(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(dbInstanceIdentifier)})
+		err := c.rds.WaitUntilDBInstanceAvailableWithContext(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(dbInstanceIdentifier)})
 		if err != nil {
 			aerr, ok := err.(awserr.Error)
 			if ok && aerr.Code() == request.WaiterResourceNotReadyErrorCode {
@@ -313,13 +500,12 @@ func waitUntilDBInstanceAvailable(dbInstanceIdentifier string) error {
 	return nil
 }
 
-func waitUntilDBClusterAvailable(dbClusterIdentifier string) error {
+func (c *Client) waitUntilDBClusterAvailable(ctx context.Context, dbClusterIdentifier string) error {
 	log.Printf("wait until Aurora Cluster %s is ready\n", dbClusterIdentifier)
-	cli := client()
 
 	maxAttempt := 120
 	for i := 0; i < maxAttempt; i++ {
-		resp, err := cli.DescribeDBClusters(&rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(dbClusterIdentifier)})
+		resp, err := c.rds.DescribeDBClustersWithContext(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(dbClusterIdentifier)})
 		if err != nil {
 			return err
 		}
@@ -327,16 +513,18 @@ func waitUntilDBClusterAvailable(dbClusterIdentifier string) error {
 			log.Printf("Aurora Cluster %s is ready\n", dbClusterIdentifier)
 			return nil
 		}
-		time.Sleep(30 * time.Second)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(30 * time.Second):
+		}
 	}
 
-	return fmt.Errorf("Aurora Cluster %s is not ready, exceed max wait attemps", dbClusterIdentifier)
+	return fmt.Errorf("Aurora Cluster %s is %w", dbClusterIdentifier, ErrNotReady)
 }
 
-func dbInstance(dbInstanceIdentifier string) (*rds.DBInstance, error) {
-	cli := client()
-
-	resp, err := cli.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+func (c *Client) dbInstance(ctx context.Context, dbInstanceIdentifier string) (*rds.DBInstance, error) {
+	resp, err := c.rds.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{
 		DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
 	})
 	if err != nil {
@@ -350,10 +538,8 @@ func dbInstance(dbInstanceIdentifier string) (*rds.DBInstance, error) {
 	return resp.DBInstances[0], nil
 }
 
-func dbCluster(dbClusterIdentifier string) (*rds.DBCluster, error) {
-	cli := client()
-
-	resp, err := cli.DescribeDBClusters(&rds.DescribeDBClustersInput{
+func (c *Client) dbCluster(ctx context.Context, dbClusterIdentifier string) (*rds.DBCluster, error) {
+	resp, err := c.rds.DescribeDBClustersWithContext(ctx, &rds.DescribeDBClustersInput{
 		DBClusterIdentifier: aws.String(dbClusterIdentifier),
 	})
 	if err != nil {
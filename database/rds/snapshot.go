@@ -0,0 +1,200 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+const snapshotStatusAvailable = "available"
+
+// snapshotCandidate is a described snapshot which newestSnapshot selects from.
+type snapshotCandidate struct {
+	Identifier string
+	Status     string
+	CreateTime time.Time
+	Tags       []*rds.Tag
+}
+
+// newestSnapshot returns the identifier of the newest available snapshot in snapshots
+// whose identifier starts with prefix and which has every tag in tags, or "" if there is none.
+func newestSnapshot(snapshots []snapshotCandidate, prefix string, tags map[string]string) string {
+	var (
+		newest     string
+		createTime time.Time
+	)
+	for _, snapshot := range snapshots {
+		if snapshot.Status != snapshotStatusAvailable || !strings.HasPrefix(snapshot.Identifier, prefix) || !hasTags(snapshot.Tags, tags) {
+			continue
+		}
+		if newest == "" || snapshot.CreateTime.After(createTime) {
+			newest, createTime = snapshot.Identifier, snapshot.CreateTime
+		}
+	}
+	return newest
+}
+
+// FindDBSnapshot returns the identifier of the newest available snapshot of the RDS Instance,
+// manual or automated, whose identifier starts with prefix and which has every tag in tags.
+func (c *Client) FindDBSnapshot(ctx context.Context, dbInstanceIdentifier, prefix string, tags map[string]string) (_ string, err error) {
+	defer wrapError(&err, "find a snapshot of", dbInstanceIdentifier)
+
+	snapshots := []snapshotCandidate{}
+	input := &rds.DescribeDBSnapshotsInput{
+		DBInstanceIdentifier: aws.String(dbInstanceIdentifier),
+	}
+	err = c.rds.DescribeDBSnapshotsPagesWithContext(ctx, input, func(resp *rds.DescribeDBSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range resp.DBSnapshots {
+			snapshots = append(snapshots, snapshotCandidate{
+				Identifier: aws.StringValue(snapshot.DBSnapshotIdentifier),
+				Status:     aws.StringValue(snapshot.Status),
+				CreateTime: aws.TimeValue(snapshot.SnapshotCreateTime),
+				Tags:       snapshot.TagList,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	newest := newestSnapshot(snapshots, prefix, tags)
+	if newest == "" {
+		return "", fmt.Errorf("no available snapshot of RDS Instance %s matches prefix %q and tags %v", dbInstanceIdentifier, prefix, tags)
+	}
+	return newest, nil
+}
+
+// FindDBClusterSnapshot returns the identifier of the newest available snapshot of the Aurora Cluster,
+// manual or automated, whose identifier starts with prefix and which has every tag in tags.
+func (c *Client) FindDBClusterSnapshot(ctx context.Context, dbClusterIdentifier, prefix string, tags map[string]string) (_ string, err error) {
+	defer wrapError(&err, "find a snapshot of", dbClusterIdentifier)
+
+	snapshots := []snapshotCandidate{}
+	input := &rds.DescribeDBClusterSnapshotsInput{
+		DBClusterIdentifier: aws.String(dbClusterIdentifier),
+	}
+	err = c.rds.DescribeDBClusterSnapshotsPagesWithContext(ctx, input, func(resp *rds.DescribeDBClusterSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range resp.DBClusterSnapshots {
+			snapshots = append(snapshots, snapshotCandidate{
+				Identifier: aws.StringValue(snapshot.DBClusterSnapshotIdentifier),
+				Status:     aws.StringValue(snapshot.Status),
+				CreateTime: aws.TimeValue(snapshot.SnapshotCreateTime),
+				Tags:       snapshot.TagList,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	newest := newestSnapshot(snapshots, prefix, tags)
+	if newest == "" {
+		return "", fmt.Errorf("no available snapshot of Aurora Cluster %s matches prefix %q and tags %v", dbClusterIdentifier, prefix, tags)
+	}
+	return newest, nil
+}
+
+func (c *Client) dbClusterSnapshot(ctx context.Context, dbClusterSnapshotIdentifier string) (*rds.DBClusterSnapshot, error) {
+	resp, err := c.rds.DescribeDBClusterSnapshotsWithContext(ctx, &rds.DescribeDBClusterSnapshotsInput{
+		DBClusterSnapshotIdentifier: aws.String(dbClusterSnapshotIdentifier),
+	})
+	if err != nil {
+		aerr, ok := err.(awserr.Error)
+		if ok && aerr.Code() == rds.ErrCodeDBClusterSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(resp.DBClusterSnapshots) == 0 {
+		return nil, nil
+	}
+
+	return resp.DBClusterSnapshots[0], nil
+}
+
+func (c *Client) dbSnapshot(ctx context.Context, dbSnapshotIdentifier string) (*rds.DBSnapshot, error) {
+	resp, err := c.rds.DescribeDBSnapshotsWithContext(ctx, &rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(dbSnapshotIdentifier),
+	})
+	if err != nil {
+		aerr, ok := err.(awserr.Error)
+		if ok && aerr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(resp.DBSnapshots) == 0 {
+		return nil, nil
+	}
+
+	return resp.DBSnapshots[0], nil
+}
+
+// DeleteDBSnapshot deletes the snapshot of an RDS Instance.
+func (c *Client) DeleteDBSnapshot(ctx context.Context, dbSnapshotIdentifier string) (err error) {
+	defer wrapError(&err, "delete snapshot", dbSnapshotIdentifier)
+
+	_, err = c.rds.DeleteDBSnapshotWithContext(ctx, &rds.DeleteDBSnapshotInput{DBSnapshotIdentifier: aws.String(dbSnapshotIdentifier)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+		return nil
+	}
+	return err
+}
+
+// DeleteDBClusterSnapshot deletes the snapshot of an Aurora Cluster.
+func (c *Client) DeleteDBClusterSnapshot(ctx context.Context, dbClusterSnapshotIdentifier string) (err error) {
+	defer wrapError(&err, "delete snapshot", dbClusterSnapshotIdentifier)
+
+	_, err = c.rds.DeleteDBClusterSnapshotWithContext(ctx, &rds.DeleteDBClusterSnapshotInput{DBClusterSnapshotIdentifier: aws.String(dbClusterSnapshotIdentifier)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBClusterSnapshotNotFoundFault {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) waitUntilDBSnapshotAvailable(ctx context.Context, dbSnapshotIdentifier string) error {
+	log.Printf("wait until RDS snapshot %s is available\n", dbSnapshotIdentifier)
+
+	for {
+		err := c.rds.WaitUntilDBSnapshotAvailableWithContext(ctx, &rds.DescribeDBSnapshotsInput{DBSnapshotIdentifier: aws.String(dbSnapshotIdentifier)})
+		if err != nil {
+			aerr, ok := err.(awserr.Error)
+			if ok && aerr.Code() == request.WaiterResourceNotReadyErrorCode {
+				continue
+			}
+			return err
+		}
+		break
+	}
+	log.Printf("RDS snapshot %s is available\n", dbSnapshotIdentifier)
+
+	return nil
+}
+
+func (c *Client) waitUntilDBClusterSnapshotAvailable(ctx context.Context, dbClusterSnapshotIdentifier string) error {
+	log.Printf("wait until Aurora Cluster snapshot %s is available\n", dbClusterSnapshotIdentifier)
+
+	for {
+		err := c.rds.WaitUntilDBClusterSnapshotAvailableWithContext(ctx, &rds.DescribeDBClusterSnapshotsInput{DBClusterSnapshotIdentifier: aws.String(dbClusterSnapshotIdentifier)})
+		if err != nil {
+			aerr, ok := err.(awserr.Error)
+			if ok && aerr.Code() == request.WaiterResourceNotReadyErrorCode {
+				continue
+			}
+			return err
+		}
+		break
+	}
+	log.Printf("Aurora Cluster snapshot %s is available\n", dbClusterSnapshotIdentifier)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package rds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func TestNewestSnapshot(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2017, 5, d, 3, 0, 0, 0, time.UTC) }
+	tags := func(kv ...string) []*rds.Tag {
+		list := []*rds.Tag{}
+		for i := 0; i < len(kv); i += 2 {
+			list = append(list, &rds.Tag{Key: aws.String(kv[i]), Value: aws.String(kv[i+1])})
+		}
+		return list
+	}
+	snapshots := []snapshotCandidate{
+		{Identifier: "rds:db-2017-05-24", Status: "available", CreateTime: day(24)},
+		{Identifier: "nightly-2017-05-25", Status: "available", CreateTime: day(25), Tags: tags("env", "staging")},
+		{Identifier: "nightly-2017-05-23", Status: "available", CreateTime: day(23), Tags: tags("env", "production", "team", "db")},
+		{Identifier: "nightly-2017-05-26", Status: "creating", CreateTime: day(26), Tags: tags("env", "production")},
+		{Identifier: "rds:db-2017-05-22", Status: "available", CreateTime: day(22), Tags: tags("env", "production")},
+	}
+
+	cases := []struct {
+		name   string
+		prefix string
+		tags   map[string]string
+		want   string
+	}{
+		{"newest", "", nil, "nightly-2017-05-25"},
+		{"prefix", "rds:", nil, "rds:db-2017-05-24"},
+		{"tag", "", map[string]string{"env": "production"}, "nightly-2017-05-23"},
+		{"every tag", "", map[string]string{"env": "production", "team": "db"}, "nightly-2017-05-23"},
+		{"prefix and tag", "rds:", map[string]string{"env": "production"}, "rds:db-2017-05-22"},
+		{"tag value", "", map[string]string{"env": "development"}, ""},
+		{"no match", "manual-", nil, ""},
+	}
+	for _, tc := range cases {
+		if got := newestSnapshot(snapshots, tc.prefix, tc.tags); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
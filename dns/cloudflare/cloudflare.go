@@ -0,0 +1,191 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/dns"
+)
+
+const defaultBaseURL = "https://api.cloudflare.com/client/v4"
+
+type Client struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	zoneID  string
+}
+
+// NewClient returns the client of the Cloudflare API.
+// If zoneID is empty, the zone is looked up by the domain of each record.
+func NewClient(token, zoneID string) dns.DNS {
+	return &Client{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: defaultBaseURL,
+		token:   token,
+		zoneID:  zoneID,
+	}
+}
+
+type record struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type zone struct {
+	ID string `json:"id"`
+}
+
+type response struct {
+	Success bool            `json:"success"`
+	Errors  []apiError      `json:"errors"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (c *Client) GetRecord(ctx context.Context, domain, name, recordType string) (*dns.Record, error) {
+	zoneID, err := c.getZoneID(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := c.getRecord(ctx, zoneID, domain, name, recordType)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	return &dns.Record{
+		Type:  current.Type,
+		Name:  name,
+		Value: current.Content,
+		TTL:   current.TTL,
+	}, nil
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, domain string, r *dns.Record) error {
+	zoneID, err := c.getZoneID(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.getRecord(ctx, zoneID, domain, r.Name, r.Type)
+	if err != nil {
+		return err
+	}
+
+	body := &record{
+		Type:    r.Type,
+		Name:    recordName(domain, r.Name),
+		Content: r.Value,
+		TTL:     r.TTL,
+	}
+	if current == nil {
+		return c.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", body, nil)
+	}
+	return c.do(ctx, http.MethodPut, "/zones/"+zoneID+"/dns_records/"+current.ID, body, nil)
+}
+
+func (c *Client) DeleteRecord(ctx context.Context, domain, name, recordType string) error {
+	zoneID, err := c.getZoneID(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.getRecord(ctx, zoneID, domain, name, recordType)
+	if err != nil || current == nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+current.ID, nil, nil)
+}
+
+func (c *Client) getZoneID(ctx context.Context, domain string) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+
+	zones := []zone{}
+	if err := c.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(domain), nil, &zones); err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", fmt.Errorf("zone %s is not found in Cloudflare", domain)
+	}
+
+	c.zoneID = zones[0].ID
+	return c.zoneID, nil
+}
+
+func (c *Client) getRecord(ctx context.Context, zoneID, domain, name, recordType string) (*record, error) {
+	query := url.Values{}
+	query.Set("type", recordType)
+	query.Set("name", recordName(domain, name))
+
+	records := []record{}
+	if err := c.do(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// do sends a request to the Cloudflare API and decodes the result of the response into result.
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	r := &response{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return fmt.Errorf("failed to decode the response of %s %s (%s): %s", method, path, resp.Status, err)
+	}
+	if !r.Success {
+		messages := make([]string, 0, len(r.Errors))
+		for _, e := range r.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", e.Code, e.Message))
+		}
+		return fmt.Errorf("Cloudflare API returned an error to %s %s (%s): %s", method, path, resp.Status, strings.Join(messages, ", "))
+	}
+
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
+
+// recordName returns the fully qualified name of the record, as Cloudflare returns it.
+func recordName(domain, name string) string {
+	if name == "" {
+		return domain
+	}
+	return name + "." + domain
+}
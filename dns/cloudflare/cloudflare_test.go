@@ -0,0 +1,116 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/munisystem/rosculus/dns"
+)
+
+// fakeCloudflare is a stand-in for the Cloudflare API which keeps the records of one zone in memory.
+type fakeCloudflare struct {
+	records map[string]*record
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"errors":  []apiError{{Code: 10000, Message: "Authentication error"}},
+		})
+		return
+	}
+
+	var result interface{}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		result = []zone{{ID: "zone"}}
+	case r.Method == http.MethodGet && r.URL.Path == "/zones/zone/dns_records":
+		records := []*record{}
+		for _, rec := range f.records {
+			if rec.Name == r.URL.Query().Get("name") && rec.Type == r.URL.Query().Get("type") {
+				records = append(records, rec)
+			}
+		}
+		result = records
+	case r.Method == http.MethodPost && r.URL.Path == "/zones/zone/dns_records":
+		rec := &record{}
+		json.NewDecoder(r.Body).Decode(rec)
+		rec.ID = rec.Name
+		f.records[rec.ID] = rec
+		result = rec
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/zones/zone/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/zones/zone/dns_records/")
+		rec := &record{}
+		json.NewDecoder(r.Body).Decode(rec)
+		rec.ID = id
+		f.records[id] = rec
+		result = rec
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/zones/zone/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/zones/zone/dns_records/")
+		delete(f.records, id)
+		result = map[string]string{"id": id}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result})
+}
+
+func newTestClient(t *testing.T, f *fakeCloudflare, token string) *Client {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	return &Client{client: server.Client(), baseURL: server.URL, token: token}
+}
+
+func TestClient_UpdateRecord(t *testing.T) {
+	f := &fakeCloudflare{records: map[string]*record{}}
+	c := newTestClient(t, f, "token")
+
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170101.rds.amazonaws.com", TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 120}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(f.records))
+	}
+	rec := f.records["db.example.com"]
+	if rec.Type != "CNAME" || rec.Content != "db-20170102.rds.amazonaws.com" || rec.TTL != 120 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+
+	want := &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 120}
+	if got, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := c.DeleteRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Errorf("expected the record to be deleted, got %+v", got)
+	}
+}
+
+func TestClient_error(t *testing.T) {
+	c := newTestClient(t, &fakeCloudflare{records: map[string]*record{}}, "wrong")
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db.rds.amazonaws.com", TTL: 60}); err == nil || !strings.Contains(err.Error(), "Authentication error") {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+}
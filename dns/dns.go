@@ -1,5 +1,30 @@
 package dns
 
+import "context"
+
+// Record types which every provider supports.
+const (
+	TypeA     = "A"
+	TypeAAAA  = "AAAA"
+	TypeCNAME = "CNAME"
+)
+
+// Record describes a DNS record.
+type Record struct {
+	Type string
+	// Name is relative to the domain, and empty for the apex.
+	Name string
+	// Value is the address of an A or AAAA record, or the target of a CNAME record without the trailing dot.
+	Value string
+	TTL   int
+}
+
+// DNS changes the records of a provider. Every method gives up when ctx is canceled.
 type DNS interface {
-	UpdateRecord(domain, name, value string, ttl int) error
+	// GetRecord returns the record, or nil if it does not exist.
+	GetRecord(ctx context.Context, domain, name, recordType string) (*Record, error)
+	// UpdateRecord creates the record, or replaces the value and the TTL of the existing one.
+	UpdateRecord(ctx context.Context, domain string, record *Record) error
+	// DeleteRecord deletes the record. It does nothing if the record does not exist.
+	DeleteRecord(ctx context.Context, domain, name, recordType string) error
 }
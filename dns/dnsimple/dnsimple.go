@@ -22,19 +22,31 @@ func NewClient(token, accountID string) dns.DNS {
 	}
 }
 
-func (c *Client) UpdateRecord(domain, name, value string, ttl int) error {
-	ctx := context.Background()
-	if recordID, err := c.getRecordID(ctx, domain, name); err != nil {
+func (c *Client) GetRecord(ctx context.Context, domain, name, recordType string) (*dns.Record, error) {
+	record, err := c.getRecord(ctx, domain, name, recordType)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return &dns.Record{
+		Type:  record.Type,
+		Name:  record.Name,
+		Value: record.Content,
+		TTL:   record.TTL,
+	}, nil
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, domain string, record *dns.Record) error {
+	if current, err := c.getRecord(ctx, domain, record.Name, record.Type); err != nil {
 		return err
-	} else if recordID == -1 {
-		return c.createRecord(ctx, domain, name, value, ttl)
+	} else if current == nil {
+		return c.createRecord(ctx, domain, record)
 	} else {
 		attributes := &dnsimple.ZoneRecordAttributes{
-			Name:    dnsimple.String(name),
-			Content: value,
-			TTL:     ttl,
+			Name:    dnsimple.String(record.Name),
+			Content: record.Value,
+			TTL:     record.TTL,
 		}
-		if _, err := c.client.Zones.UpdateRecord(context.Background(), c.accountID, domain, recordID, *attributes); err != nil {
+		if _, err := c.client.Zones.UpdateRecord(ctx, c.accountID, domain, current.ID, *attributes); err != nil {
 			return err
 		}
 	}
@@ -42,27 +54,40 @@ func (c *Client) UpdateRecord(domain, name, value string, ttl int) error {
 	return nil
 }
 
-func (c *Client) getRecordID(ctx context.Context, domain, name string) (int64, error) {
+func (c *Client) DeleteRecord(ctx context.Context, domain, name, recordType string) error {
+	record, err := c.getRecord(ctx, domain, name, recordType)
+	if err != nil || record == nil {
+		return err
+	}
+
+	if _, err := c.client.Zones.DeleteRecord(ctx, c.accountID, domain, record.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) getRecord(ctx context.Context, domain, name, recordType string) (*dnsimple.ZoneRecord, error) {
 	options := &dnsimple.ZoneRecordListOptions{
 		Name: dnsimple.String(name),
-		Type: dnsimple.String("CNAME"),
+		Type: dnsimple.String(recordType),
 	}
 	resp, err := c.client.Zones.ListRecords(ctx, c.accountID, domain, options)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 	if len(resp.Data) == 0 {
-		return -1, nil
+		return nil, nil
 	}
-	return resp.Data[0].ID, nil
+	return &resp.Data[0], nil
 }
 
-func (c *Client) createRecord(ctx context.Context, domain, name, value string, ttl int) error {
+func (c *Client) createRecord(ctx context.Context, domain string, record *dns.Record) error {
 	attributes := &dnsimple.ZoneRecordAttributes{
-		Name:    dnsimple.String(name),
-		Type:    "CNAME",
-		Content: value,
-		TTL:     ttl,
+		Name:    dnsimple.String(record.Name),
+		Type:    record.Type,
+		Content: record.Value,
+		TTL:     record.TTL,
 	}
 	if _, err := c.client.Zones.CreateRecord(ctx, c.accountID, domain, *attributes); err != nil {
 		return err
@@ -0,0 +1,82 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Error is a failure of a DNS provider to look up or change a record.
+type Error struct {
+	Provider string
+	// Op is "get", "update" or "delete".
+	Op     string
+	Domain string
+	Name   string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether the failure is temporary, such as a timeout of the network.
+func (e *Error) Temporary() bool {
+	var t interface{ Temporary() bool }
+	return errors.As(e.Err, &t) && t.Temporary()
+}
+
+// PropagationError is returned when the nameservers do not answer the value of a record within the timeout.
+type PropagationError struct {
+	FQDN  string
+	Type  string
+	Value string
+	// Pending is the nameservers which do not answer the value.
+	Pending []string
+	Timeout time.Duration
+}
+
+func (e *PropagationError) Error() string {
+	return fmt.Sprintf("%s %s did not resolve to %s on %s within %s", e.FQDN, e.Type, e.Value, strings.Join(e.Pending, ", "), e.Timeout)
+}
+
+// Temporary reports true, as the record may propagate later.
+func (e *PropagationError) Temporary() bool {
+	return true
+}
+
+type errorDNS struct {
+	provider string
+	dns      DNS
+}
+
+// WrapErrors returns the DNS which returns the errors of the provider as *Error.
+func WrapErrors(provider string, dns DNS) DNS {
+	return &errorDNS{provider: provider, dns: dns}
+}
+
+func (d *errorDNS) GetRecord(ctx context.Context, domain, name, recordType string) (*Record, error) {
+	record, err := d.dns.GetRecord(ctx, domain, name, recordType)
+	return record, d.wrap("get", domain, name, err)
+}
+
+func (d *errorDNS) UpdateRecord(ctx context.Context, domain string, record *Record) error {
+	return d.wrap("update", domain, record.Name, d.dns.UpdateRecord(ctx, domain, record))
+}
+
+func (d *errorDNS) DeleteRecord(ctx context.Context, domain, name, recordType string) error {
+	return d.wrap("delete", domain, name, d.dns.DeleteRecord(ctx, domain, name, recordType))
+}
+
+func (d *errorDNS) wrap(op, domain, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Provider: d.provider, Op: op, Domain: domain, Name: name, Err: err}
+}
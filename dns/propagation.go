@@ -0,0 +1,116 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// queryTimeout is the timeout of a single query to a nameserver.
+const queryTimeout = 5 * time.Second
+
+// Propagation polls nameservers until a record has the expected value.
+type Propagation struct {
+	// Nameservers are the "host:port" addresses to query. If Recursive is false,
+	// they have to be authoritative for the domain.
+	Nameservers []string
+	Recursive   bool
+	Timeout     time.Duration
+	Interval    time.Duration
+}
+
+// AuthoritativeNameservers returns the addresses of the authoritative nameservers of the domain.
+func AuthoritativeNameservers(ctx context.Context, domain string) ([]string, error) {
+	nss, err := net.DefaultResolver.LookupNS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, 0, len(nss))
+	for _, ns := range nss {
+		addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(ns.Host, "."), "53"))
+	}
+	return addrs, nil
+}
+
+// Wait returns nil once every nameserver answers the value of the record,
+// or a *PropagationError if they do not within the timeout. It stops waiting when ctx is canceled.
+func (p *Propagation) Wait(ctx context.Context, domain string, record *Record) error {
+	rrtype, ok := mdns.StringToType[record.Type]
+	if !ok {
+		return fmt.Errorf("unknown record type %s", record.Type)
+	}
+
+	fqdn := mdns.Fqdn(domain)
+	if record.Name != "" {
+		fqdn = mdns.Fqdn(record.Name + "." + domain)
+	}
+
+	client := &mdns.Client{Timeout: queryTimeout}
+	deadline := time.Now().Add(p.Timeout)
+	for {
+		pending := []string{}
+		for _, ns := range p.Nameservers {
+			value, err := p.query(ctx, client, ns, fqdn, rrtype)
+			if err != nil || !matches(record, value) {
+				pending = append(pending, ns)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if time.Now().Add(p.Interval).After(deadline) {
+			return &PropagationError{FQDN: fqdn, Type: record.Type, Value: record.Value, Pending: pending, Timeout: p.Timeout}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.Interval):
+		}
+	}
+}
+
+// query returns the value of the record of rrtype at fqdn which the nameserver answers.
+func (p *Propagation) query(ctx context.Context, client *mdns.Client, ns, fqdn string, rrtype uint16) (string, error) {
+	m := new(mdns.Msg)
+	m.SetQuestion(fqdn, rrtype)
+	m.RecursionDesired = p.Recursive
+
+	r, _, err := client.ExchangeContext(ctx, m, ns)
+	if err != nil {
+		return "", err
+	}
+	if r.Rcode != mdns.RcodeSuccess {
+		return "", fmt.Errorf("%s answered %s", ns, mdns.RcodeToString[r.Rcode])
+	}
+
+	for _, rr := range r.Answer {
+		// Names are case-insensitive, and nameservers may answer them in another case than asked.
+		if !strings.EqualFold(rr.Header().Name, fqdn) || rr.Header().Rrtype != rrtype {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *mdns.CNAME:
+			return strings.TrimSuffix(rr.Target, "."), nil
+		case *mdns.A:
+			return rr.A.String(), nil
+		case *mdns.AAAA:
+			return rr.AAAA.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// matches reports whether the value which a nameserver answers is the value of the record.
+// The targets of CNAME records are compared case-insensitively.
+func matches(record *Record, value string) bool {
+	if record.Type == TypeCNAME {
+		return strings.EqualFold(value, strings.TrimSuffix(record.Value, "."))
+	}
+	return value == record.Value
+}
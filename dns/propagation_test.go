@@ -0,0 +1,120 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// fakeNameserver answers the CNAME record which the test sets.
+// If upper is true, it answers the names in upper case.
+type fakeNameserver struct {
+	mu     sync.Mutex
+	target string
+	upper  bool
+}
+
+func (f *fakeNameserver) set(target string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.target = target
+}
+
+func (f *fakeNameserver) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name, target := r.Question[0].Name, mdns.Fqdn(f.target)
+	if f.upper {
+		name, target = strings.ToUpper(name), strings.ToUpper(target)
+	}
+
+	reply := new(mdns.Msg)
+	reply.SetReply(r)
+	reply.Answer = append(reply.Answer, &mdns.CNAME{
+		Hdr:    mdns.RR_Header{Name: name, Rrtype: mdns.TypeCNAME, Class: mdns.ClassINET, Ttl: 60},
+		Target: target,
+	})
+	w.WriteMsg(reply)
+}
+
+func startNameserver(t *testing.T, f *fakeNameserver) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	server := &mdns.Server{PacketConn: pc, Handler: f, NotifyStartedFunc: func() { close(started) }}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	<-started
+
+	return pc.LocalAddr().String()
+}
+
+func TestPropagation_Wait(t *testing.T) {
+	f := &fakeNameserver{target: "db-20170101.rds.amazonaws.com"}
+	p := &Propagation{
+		Nameservers: []string{startNameserver(t, f)},
+		Timeout:     5 * time.Second,
+		Interval:    10 * time.Millisecond,
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { f.set("db-20170102.rds.amazonaws.com") })
+
+	record := &Record{Type: TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com"}
+	if err := p.Wait(context.Background(), "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPropagation_Wait_case(t *testing.T) {
+	f := &fakeNameserver{target: "db-20170102.rds.amazonaws.com", upper: true}
+	p := &Propagation{
+		Nameservers: []string{startNameserver(t, f)},
+		Timeout:     time.Second,
+		Interval:    10 * time.Millisecond,
+	}
+
+	record := &Record{Type: TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com"}
+	if err := p.Wait(context.Background(), "Example.com", record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPropagation_Wait_timeout(t *testing.T) {
+	f := &fakeNameserver{target: "db-20170101.rds.amazonaws.com"}
+	p := &Propagation{
+		Nameservers: []string{startNameserver(t, f)},
+		Timeout:     50 * time.Millisecond,
+		Interval:    10 * time.Millisecond,
+	}
+
+	record := &Record{Type: TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com"}
+	if err := p.Wait(context.Background(), "example.com", record); err == nil {
+		t.Error("expected the wait to time out")
+	}
+}
+
+func TestPropagation_Wait_canceled(t *testing.T) {
+	f := &fakeNameserver{target: "db-20170101.rds.amazonaws.com"}
+	p := &Propagation{
+		Nameservers: []string{startNameserver(t, f)},
+		Timeout:     5 * time.Second,
+		Interval:    10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	record := &Record{Type: TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com"}
+	if err := p.Wait(ctx, "example.com", record); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
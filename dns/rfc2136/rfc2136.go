@@ -0,0 +1,166 @@
+package rfc2136
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/munisystem/rosculus/dns"
+)
+
+const (
+	defaultAlgorithm = mdns.HmacSHA256
+	defaultTimeout   = 10 * time.Second
+	// tsigFudge is the permitted clock skew between rosculus and the server in seconds.
+	tsigFudge = 300
+)
+
+type Client struct {
+	client    *mdns.Client
+	server    string
+	keyName   string
+	algorithm string
+}
+
+// NewClient returns the client which sends dynamic updates to the authoritative server.
+// server is "host:port". If keyName is empty, the messages are not signed with TSIG.
+func NewClient(server, keyName, algorithm, secret string) dns.DNS {
+	if algorithm == "" {
+		algorithm = defaultAlgorithm
+	}
+
+	client := &mdns.Client{
+		Net:     "tcp",
+		Timeout: defaultTimeout,
+	}
+	if keyName != "" {
+		keyName = mdns.Fqdn(keyName)
+		client.TsigSecret = map[string]string{keyName: secret}
+	}
+
+	return &Client{
+		client:    client,
+		server:    server,
+		keyName:   keyName,
+		algorithm: mdns.Fqdn(strings.ToLower(algorithm)),
+	}
+}
+
+func (c *Client) GetRecord(ctx context.Context, domain, name, recordType string) (*dns.Record, error) {
+	fqdn := recordName(domain, name)
+	rrtype, ok := mdns.StringToType[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unknown record type %s", recordType)
+	}
+
+	m := new(mdns.Msg)
+	m.SetQuestion(fqdn, rrtype)
+	m.RecursionDesired = false
+
+	r, err := c.exchange(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if r.Rcode == mdns.RcodeNameError {
+		return nil, nil
+	}
+	if r.Rcode != mdns.RcodeSuccess {
+		return nil, fmt.Errorf("failed to look up %s: %s", fqdn, mdns.RcodeToString[r.Rcode])
+	}
+
+	for _, rr := range r.Answer {
+		if rr.Header().Rrtype != rrtype {
+			continue
+		}
+
+		record := &dns.Record{
+			Type: recordType,
+			Name: name,
+			TTL:  int(rr.Header().Ttl),
+		}
+		switch rr := rr.(type) {
+		case *mdns.CNAME:
+			record.Value = strings.TrimSuffix(rr.Target, ".")
+		case *mdns.A:
+			record.Value = rr.A.String()
+		case *mdns.AAAA:
+			record.Value = rr.AAAA.String()
+		}
+		return record, nil
+	}
+	return nil, nil
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, domain string, record *dns.Record) error {
+	fqdn := recordName(domain, record.Name)
+
+	value := record.Value
+	if record.Type == dns.TypeCNAME {
+		value = mdns.Fqdn(value)
+	}
+	rr, err := mdns.NewRR(fmt.Sprintf("%s %d IN %s %s", fqdn, record.TTL, record.Type, value))
+	if err != nil {
+		return err
+	}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(mdns.Fqdn(domain))
+	m.RemoveRRset([]mdns.RR{rrset(fqdn, rr.Header().Rrtype)})
+	m.Insert([]mdns.RR{rr})
+
+	return c.update(ctx, m, fqdn)
+}
+
+func (c *Client) DeleteRecord(ctx context.Context, domain, name, recordType string) error {
+	fqdn := recordName(domain, name)
+	rrtype, ok := mdns.StringToType[recordType]
+	if !ok {
+		return fmt.Errorf("unknown record type %s", recordType)
+	}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(mdns.Fqdn(domain))
+	m.RemoveRRset([]mdns.RR{rrset(fqdn, rrtype)})
+
+	return c.update(ctx, m, fqdn)
+}
+
+// rrset returns the RR which stands for every record of rrtype at fqdn in an update.
+func rrset(fqdn string, rrtype uint16) mdns.RR {
+	return &mdns.ANY{Hdr: mdns.RR_Header{Name: fqdn, Rrtype: rrtype, Class: mdns.ClassINET}}
+}
+
+func (c *Client) update(ctx context.Context, m *mdns.Msg, fqdn string) error {
+	r, err := c.exchange(ctx, m)
+	if err != nil {
+		return err
+	}
+	if r.Rcode != mdns.RcodeSuccess {
+		return fmt.Errorf("server refused to update %s: %s", fqdn, mdns.RcodeToString[r.Rcode])
+	}
+
+	return nil
+}
+
+// exchange signs m with TSIG if the key is configured and sends it to the server.
+func (c *Client) exchange(ctx context.Context, m *mdns.Msg) (*mdns.Msg, error) {
+	if c.keyName != "" {
+		m.SetTsig(c.keyName, c.algorithm, tsigFudge, time.Now().Unix())
+	}
+
+	r, _, err := c.client.ExchangeContext(ctx, m, c.server)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// recordName returns the fully qualified name of the record.
+func recordName(domain, name string) string {
+	if name == "" {
+		return mdns.Fqdn(domain)
+	}
+	return mdns.Fqdn(name + "." + domain)
+}
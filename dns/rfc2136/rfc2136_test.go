@@ -0,0 +1,157 @@
+package rfc2136
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/munisystem/rosculus/dns"
+)
+
+const (
+	testKeyName = "rosculus."
+	testSecret  = "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+)
+
+// fakeServer is an in-process authoritative server which accepts TSIG-signed dynamic updates.
+type fakeServer struct {
+	mu      sync.Mutex
+	records map[string]mdns.RR
+}
+
+func recordKey(name string, rrtype uint16) string {
+	return name + " " + mdns.TypeToString[rrtype]
+}
+
+func (f *fakeServer) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reply := new(mdns.Msg)
+	reply.SetReply(r)
+
+	if r.Opcode == mdns.OpcodeUpdate {
+		if r.IsTsig() == nil || w.TsigStatus() != nil {
+			reply.Rcode = mdns.RcodeNotAuth
+			w.WriteMsg(reply)
+			return
+		}
+		for _, rr := range r.Ns {
+			key := recordKey(rr.Header().Name, rr.Header().Rrtype)
+			switch rr.Header().Class {
+			case mdns.ClassANY:
+				delete(f.records, key)
+			case mdns.ClassINET:
+				f.records[key] = rr
+			}
+		}
+		reply.SetTsig(testKeyName, mdns.HmacSHA256, 300, time.Now().Unix())
+		w.WriteMsg(reply)
+		return
+	}
+
+	if record, ok := f.records[recordKey(r.Question[0].Name, r.Question[0].Qtype)]; ok {
+		reply.Answer = append(reply.Answer, record)
+	} else {
+		reply.Rcode = mdns.RcodeNameError
+	}
+	if r.IsTsig() != nil {
+		reply.SetTsig(testKeyName, mdns.HmacSHA256, 300, time.Now().Unix())
+	}
+	w.WriteMsg(reply)
+}
+
+func startServer(t *testing.T, f *fakeServer) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	server := &mdns.Server{
+		Listener:          l,
+		Net:               "tcp",
+		Handler:           f,
+		TsigSecret:        map[string]string{testKeyName: testSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accept func answers NOTIMP to dynamic updates.
+		MsgAcceptFunc: func(dh mdns.Header) mdns.MsgAcceptAction { return mdns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	<-started
+
+	return l.Addr().String()
+}
+
+func TestClient_UpdateRecord(t *testing.T) {
+	f := &fakeServer{records: map[string]mdns.RR{}}
+	addr := startServer(t, f)
+
+	c := NewClient(addr, "rosculus", "", testSecret)
+
+	if record, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if record != nil {
+		t.Errorf("expected no record, got %+v", record)
+	}
+
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170101.rds.amazonaws.com", TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	record, ok := f.records["db.example.com. CNAME"].(*mdns.CNAME)
+	if !ok || record.Target != "db-20170102.rds.amazonaws.com." || record.Hdr.Ttl != 30 {
+		t.Errorf("unexpected record %v", f.records)
+	}
+
+	want := &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 30}
+	if got, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestClient_DeleteRecord(t *testing.T) {
+	f := &fakeServer{records: map[string]mdns.RR{}}
+	addr := startServer(t, f)
+
+	c := NewClient(addr, "rosculus", "", testSecret)
+
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeAAAA, Name: "db", Value: "2001:db8::1", TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeAAAA); err != nil {
+		t.Fatal(err)
+	} else if got == nil || got.Value != "2001:db8::1" {
+		t.Errorf("unexpected record %+v", got)
+	}
+
+	if err := c.DeleteRecord(context.Background(), "example.com", "db", dns.TypeAAAA); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.records) != 0 {
+		t.Errorf("expected the record to be deleted, got %v", f.records)
+	}
+}
+
+func TestClient_UpdateRecord_wrongKey(t *testing.T) {
+	f := &fakeServer{records: map[string]mdns.RR{}}
+	addr := startServer(t, f)
+
+	c := NewClient(addr, "rosculus", "", "d3Jvbmctc2VjcmV0")
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db.rds.amazonaws.com", TTL: 60}); err == nil {
+		t.Error("expected the update signed with a wrong key to fail")
+	}
+	if len(f.records) != 0 {
+		t.Errorf("expected no record, got %v", f.records)
+	}
+}
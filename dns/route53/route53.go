@@ -0,0 +1,130 @@
+package route53
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/munisystem/rosculus/dns"
+)
+
+type Client struct {
+	client       route53iface.Route53API
+	hostedZoneID string
+}
+
+func NewClient(sess *session.Session, hostedZoneID string) dns.DNS {
+	return &Client{
+		client:       route53.New(sess),
+		hostedZoneID: hostedZoneID,
+	}
+}
+
+// PrivateZone reports whether the hosted zone is private, whose records resolve only in its VPCs.
+func PrivateZone(ctx context.Context, sess *session.Session, hostedZoneID string) (bool, error) {
+	c := &Client{client: route53.New(sess), hostedZoneID: hostedZoneID}
+	return c.privateZone(ctx)
+}
+
+func (c *Client) privateZone(ctx context.Context) (bool, error) {
+	resp, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(c.hostedZoneID)})
+	if err != nil {
+		return false, err
+	}
+	return resp.HostedZone.Config != nil && aws.BoolValue(resp.HostedZone.Config.PrivateZone), nil
+}
+
+func (c *Client) GetRecord(ctx context.Context, domain, name, recordType string) (*dns.Record, error) {
+	set, err := c.getResourceRecordSet(ctx, domain, name, recordType)
+	if err != nil || set == nil {
+		return nil, err
+	}
+
+	return &dns.Record{
+		Type:  recordType,
+		Name:  name,
+		Value: strings.TrimSuffix(aws.StringValue(set.ResourceRecords[0].Value), "."),
+		TTL:   int(aws.Int64Value(set.TTL)),
+	}, nil
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, domain string, record *dns.Record) error {
+	set := &route53.ResourceRecordSet{
+		Name: aws.String(recordName(domain, record.Name)),
+		Type: aws.String(record.Type),
+		TTL:  aws.Int64(int64(record.TTL)),
+		ResourceRecords: []*route53.ResourceRecord{
+			{Value: aws.String(record.Value)},
+		},
+	}
+	return c.changeResourceRecordSet(ctx, route53.ChangeActionUpsert, set)
+}
+
+func (c *Client) DeleteRecord(ctx context.Context, domain, name, recordType string) error {
+	// Route 53 deletes a record set only if every value matches the current one.
+	set, err := c.getResourceRecordSet(ctx, domain, name, recordType)
+	if err != nil || set == nil {
+		return err
+	}
+	return c.changeResourceRecordSet(ctx, route53.ChangeActionDelete, set)
+}
+
+func (c *Client) getResourceRecordSet(ctx context.Context, domain, name, recordType string) (*route53.ResourceRecordSet, error) {
+	fqdn := recordName(domain, name)
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(c.hostedZoneID),
+		StartRecordName: aws.String(fqdn),
+		StartRecordType: aws.String(recordType),
+		MaxItems:        aws.String("1"),
+	}
+	resp, err := c.client.ListResourceRecordSetsWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// ListResourceRecordSets starts from the given name, so the first record
+	// is the next one in the zone if the record does not exist.
+	if len(resp.ResourceRecordSets) == 0 {
+		return nil, nil
+	}
+	// Route 53 returns the names in lower case, which match whatever case the config uses.
+	set := resp.ResourceRecordSets[0]
+	if !strings.EqualFold(aws.StringValue(set.Name), fqdn) || aws.StringValue(set.Type) != recordType || len(set.ResourceRecords) == 0 {
+		return nil, nil
+	}
+
+	return set, nil
+}
+
+func (c *Client) changeResourceRecordSet(ctx context.Context, action string, set *route53.ResourceRecordSet) error {
+	input := &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(c.hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String("rotated by rosculus"),
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(action),
+					ResourceRecordSet: set,
+				},
+			},
+		},
+	}
+	if _, err := c.client.ChangeResourceRecordSetsWithContext(ctx, input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recordName returns the fully qualified name of the record, as Route 53 returns it.
+func recordName(domain, name string) string {
+	domain = strings.TrimSuffix(domain, ".") + "."
+	if name == "" {
+		return domain
+	}
+	return name + "." + domain
+}
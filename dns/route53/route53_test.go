@@ -0,0 +1,200 @@
+package route53
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/munisystem/rosculus/dns"
+)
+
+type resourceRecordSet struct {
+	Name   string   `xml:"Name"`
+	Type   string   `xml:"Type"`
+	TTL    int      `xml:"TTL"`
+	Values []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+// fakeRoute53 is a stand-in for the Route 53 API which keeps the records of one hosted zone in memory.
+type fakeRoute53 struct {
+	mu      sync.Mutex
+	records map[string]resourceRecordSet
+	private bool
+}
+
+func (f *fakeRoute53) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/2013-04-01/hostedzone/ZONE" {
+		fmt.Fprintf(w, `<GetHostedZoneResponse><HostedZone><Id>/hostedzone/ZONE</Id><Name>example.com.</Name><CallerReference>ref</CallerReference><Config><PrivateZone>%t</PrivateZone></Config></HostedZone></GetHostedZoneResponse>`, f.private)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/2013-04-01/hostedzone/ZONE/rrset") {
+		http.Error(w, "<ErrorResponse><Error><Code>NoSuchHostedZone</Code></Error></ErrorResponse>", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Changes []struct {
+				Action string            `xml:"Action"`
+				Set    resourceRecordSet `xml:"ResourceRecordSet"`
+			} `xml:"ChangeBatch>Changes>Change"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, change := range req.Changes {
+			key := change.Set.Name + " " + change.Set.Type
+			if change.Action == "DELETE" {
+				delete(f.records, key)
+			} else {
+				f.records[key] = change.Set
+			}
+		}
+		w.Write([]byte(`<ChangeResourceRecordSetsResponse><ChangeInfo><Id>/change/C1</Id><Status>PENDING</Status><SubmittedAt>2017-01-01T00:00:00Z</SubmittedAt></ChangeInfo></ChangeResourceRecordSetsResponse>`))
+	case http.MethodGet:
+		start := r.URL.Query().Get("name") + " " + r.URL.Query().Get("type")
+		keys := []string{}
+		for key := range f.records {
+			if key >= start {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		var resp struct {
+			XMLName xml.Name            `xml:"ListResourceRecordSetsResponse"`
+			Sets    []resourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+			MaxItem string              `xml:"MaxItems"`
+		}
+		resp.MaxItem = "1"
+		if len(keys) > 0 {
+			resp.Sets = append(resp.Sets, f.records[keys[0]])
+		}
+		xml.NewEncoder(w).Encode(resp)
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeRoute53) *Client {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	return &Client{
+		client:       route53.New(sess),
+		hostedZoneID: "ZONE",
+	}
+}
+
+func TestClient_UpdateRecord(t *testing.T) {
+	f := &fakeRoute53{records: map[string]resourceRecordSet{
+		"zzz.example.com. CNAME": {Name: "zzz.example.com.", Type: "CNAME", TTL: 60, Values: []string{"other.example.com"}},
+	}}
+	c := newTestClient(t, f)
+
+	if record, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if record != nil {
+		t.Errorf("expected no record, got %+v", record)
+	}
+
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170101.rds.amazonaws.com", TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateRecord(context.Background(), "example.com", &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	set := f.records["db.example.com. CNAME"]
+	if set.TTL != 30 || len(set.Values) != 1 || set.Values[0] != "db-20170102.rds.amazonaws.com" {
+		t.Errorf("unexpected record %+v", set)
+	}
+
+	want := &dns.Record{Type: dns.TypeCNAME, Name: "db", Value: "db-20170102.rds.amazonaws.com", TTL: 30}
+	if record, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %+v, want %+v", record, want)
+	}
+}
+
+func TestClient_DeleteRecord(t *testing.T) {
+	f := &fakeRoute53{records: map[string]resourceRecordSet{
+		"db.example.com. A": {Name: "db.example.com.", Type: "A", TTL: 60, Values: []string{"192.0.2.1"}},
+	}}
+	c := newTestClient(t, f)
+
+	if record, err := c.GetRecord(context.Background(), "example.com", "db", dns.TypeA); err != nil {
+		t.Fatal(err)
+	} else if record == nil || record.Value != "192.0.2.1" {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	if err := c.DeleteRecord(context.Background(), "example.com", "db", dns.TypeA); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.records) != 0 {
+		t.Errorf("expected the record to be deleted, got %+v", f.records)
+	}
+
+	// Deleting a record which does not exist is not an error.
+	if err := c.DeleteRecord(context.Background(), "example.com", "db", dns.TypeA); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_GetRecord_case(t *testing.T) {
+	f := &fakeRoute53{records: map[string]resourceRecordSet{
+		"db.example.com. CNAME": {Name: "db.example.com.", Type: "CNAME", TTL: 60, Values: []string{"db-20170101.rds.amazonaws.com"}},
+	}}
+	c := newTestClient(t, f)
+
+	record, err := c.GetRecord(context.Background(), "Example.com", "DB", dns.TypeCNAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "db-20170101.rds.amazonaws.com" {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	if err := c.DeleteRecord(context.Background(), "Example.com", "DB", dns.TypeCNAME); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.records) != 0 {
+		t.Errorf("expected the record to be deleted, got %+v", f.records)
+	}
+}
+
+func TestClient_privateZone(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		c := newTestClient(t, &fakeRoute53{private: private})
+
+		got, err := c.privateZone(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != private {
+			t.Errorf("got %t, want %t", got, private)
+		}
+	}
+}
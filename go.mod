@@ -11,6 +11,7 @@ require (
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/lib/pq v0.0.0-20170707053602-dd1fe2071026
 	github.com/mattn/go-isatty v0.0.2-0.20170307163044-57fdcb988a5c // indirect
+	github.com/miekg/dns v1.1.43
 	github.com/mitchellh/cli v0.0.0-20170303023654-8d6d9ab3c912
 	github.com/smartystreets/goconvey v1.6.4 // indirect
 	github.com/stretchr/testify v1.7.0 // indirect
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,6 +13,38 @@ import (
 
 const RETRY = 10
 
+// ConnectionError is returned when PostgreSQL cannot be connected.
+type ConnectionError struct {
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return "failed to connect PostgreSQL: " + e.Err.Error()
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// QueryError is returned when a query fails. The transaction of the queries is rolled back.
+type QueryError struct {
+	// Index is the index of the query in the queries, or -1 if the transaction failed to commit.
+	Index int
+	Query string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("failed to commit the queries: %s", e.Err)
+	}
+	return fmt.Sprintf("query #%d failed: %s", e.Index+1, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
 type PostgreSQL struct {
 	ConnectionURL string
 	db            *sql.DB
@@ -21,9 +54,9 @@ func Initialize(ConnectionURL string) *PostgreSQL {
 	return &PostgreSQL{ConnectionURL: ConnectionURL}
 }
 
-func (p *PostgreSQL) connection() (*sql.DB, error) {
+func (p *PostgreSQL) connection(ctx context.Context) (*sql.DB, error) {
 	if p.db != nil {
-		if err := p.db.Ping(); err == nil {
+		if err := p.db.PingContext(ctx); err == nil {
 			return p.db, nil
 		}
 		p.db.Close()
@@ -31,60 +64,69 @@ func (p *PostgreSQL) connection() (*sql.DB, error) {
 
 	db, err := sql.Open("postgres", p.ConnectionURL)
 	if err != nil {
-		return nil, err
+		return nil, &ConnectionError{Err: err}
 	}
 
-	if !waitReady(db) {
-		return nil, errors.New("failed to connect PostgreSQL")
+	if err := waitReady(ctx, db); err != nil {
+		return nil, &ConnectionError{Err: err}
 	}
 
 	return db, nil
 }
 
-func (p *PostgreSQL) RunQueries(queries []string) error {
-	db, err := p.connection()
+// RunQueries runs the queries in a transaction and returns the number of the rows affected by each of them.
+// It returns a *ConnectionError if PostgreSQL cannot be connected and a *QueryError if a query fails.
+// Canceling ctx rolls the transaction back.
+func (p *PostgreSQL) RunQueries(ctx context.Context, queries []string) ([]int64, error) {
+	db, err := p.connection(ctx)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, &ConnectionError{Err: err}
 	}
 
 	defer func() {
 		tx.Rollback()
 	}()
 
-	for _, query := range queries {
-		if _, err = tx.Exec(query); err != nil {
-			return err
+	rows := make([]int64, len(queries))
+	for i, query := range queries {
+		result, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return nil, &QueryError{Index: i, Query: query, Err: err}
+		}
+		if rows[i], err = result.RowsAffected(); err != nil {
+			rows[i] = -1
 		}
 	}
 
 	if err = tx.Commit(); err != nil {
-		return err
+		return nil, &QueryError{Index: -1, Query: "COMMIT", Err: err}
 	}
-	return nil
+	return rows, nil
 }
 
-func waitReady(db *sql.DB) bool {
-	ready := false
+func waitReady(ctx context.Context, db *sql.DB) error {
 	for i := 0; i < RETRY; i++ {
 		log.Println("wait until PostgreSQL is ready...")
-		if err := db.Ping(); err == nil {
-			ready = true
-			break
+		if err := db.PingContext(ctx); err == nil {
+			fmt.Print("\n")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return ctx.Err()
+		case <-time.After(30 * time.Second):
 		}
-		time.Sleep(30 * time.Second)
 	}
 	fmt.Print("\n")
 
 	// If not ready PostgreSQL after 5m, then close connection.
-	if !ready {
-		db.Close()
-	}
-
-	return ready
+	db.Close()
+	return errors.New("PostgreSQL is not ready")
 }
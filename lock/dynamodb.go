@@ -0,0 +1,135 @@
+package lock
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// The attributes of the items of the leases. LockKey is the partition key of the table.
+const (
+	attributeKey        = "LockKey"
+	attributeOwner      = "Owner"
+	attributeAcquiredAt = "AcquiredAt"
+	attributeExpiresAt  = "ExpiresAt"
+)
+
+type dynamoDBBackend struct {
+	client dynamodbiface.DynamoDBAPI
+	table  string
+}
+
+// NewDynamoDBBackend returns the backend which keeps every lease in an item of the table,
+// whose partition key is the string LockKey. The leases are written with conditional writes.
+func NewDynamoDBBackend(sess *session.Session, table string) Backend {
+	return &dynamoDBBackend{client: dynamodb.New(sess), table: table}
+}
+
+// names are the names of the attributes in the expressions, as Owner is a reserved word.
+var names = map[string]*string{
+	"#key":     aws.String(attributeKey),
+	"#owner":   aws.String(attributeOwner),
+	"#expires": aws.String(attributeExpiresAt),
+}
+
+func (b *dynamoDBBackend) Acquire(ctx context.Context, lease *Lease) error {
+	for {
+		_, err := b.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(b.table),
+			Item: map[string]*dynamodb.AttributeValue{
+				attributeKey:        {S: aws.String(lease.Key)},
+				attributeOwner:      {S: aws.String(lease.Owner)},
+				attributeAcquiredAt: {N: aws.String(unixNano(lease.AcquiredAt))},
+				attributeExpiresAt:  {N: aws.String(unixNano(lease.ExpiresAt))},
+			},
+			ConditionExpression:      aws.String("attribute_not_exists(#key) OR #owner = :owner OR #expires <= :now"),
+			ExpressionAttributeNames: names,
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":owner": {S: aws.String(lease.Owner)},
+				":now":   {N: aws.String(unixNano(time.Now()))},
+			},
+		})
+		if !isConditionalCheckFailed(err) {
+			return err
+		}
+
+		resp, err := b.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(b.table),
+			Key:            map[string]*dynamodb.AttributeValue{attributeKey: {S: aws.String(lease.Key)}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+		// The lease was released or has just expired.
+		if resp.Item == nil {
+			continue
+		}
+		current := &Lease{
+			Key:        lease.Key,
+			Owner:      aws.StringValue(resp.Item[attributeOwner].S),
+			AcquiredAt: fromUnixNano(resp.Item[attributeAcquiredAt]),
+			ExpiresAt:  fromUnixNano(resp.Item[attributeExpiresAt]),
+		}
+		if !current.expired(time.Now()) {
+			return &HeldError{Lease: current}
+		}
+	}
+}
+
+func (b *dynamoDBBackend) Renew(ctx context.Context, lease *Lease) error {
+	_, err := b.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(b.table),
+		Key:                      map[string]*dynamodb.AttributeValue{attributeKey: {S: aws.String(lease.Key)}},
+		UpdateExpression:         aws.String("SET #expires = :expires"),
+		ConditionExpression:      aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]*string{"#owner": names["#owner"], "#expires": names["#expires"]},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner":   {S: aws.String(lease.Owner)},
+			":expires": {N: aws.String(unixNano(lease.ExpiresAt))},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return ErrLost
+	}
+	return err
+}
+
+func (b *dynamoDBBackend) Release(ctx context.Context, lease *Lease) error {
+	_, err := b.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(b.table),
+		Key:                      map[string]*dynamodb.AttributeValue{attributeKey: {S: aws.String(lease.Key)}},
+		ConditionExpression:      aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]*string{"#owner": names["#owner"]},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(lease.Owner)},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return nil
+	}
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func fromUnixNano(v *dynamodb.AttributeValue) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	n, _ := strconv.ParseInt(aws.StringValue(v.N), 10, 64)
+	return time.Unix(0, n)
+}
@@ -0,0 +1,141 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileBackend struct {
+	dir string
+}
+
+// NewFileBackend returns the backend which keeps every lease in a file "<dir>/<key>.lock".
+// It only keeps apart the runners which share the directory, such as the ones on the same host.
+func NewFileBackend(dir string) Backend {
+	return &fileBackend{dir: dir}
+}
+
+func (b *fileBackend) path(key string) string {
+	return filepath.Join(b.dir, key+".lock")
+}
+
+func (b *fileBackend) Acquire(ctx context.Context, lease *Lease) error {
+	if err := os.MkdirAll(b.dir, 0700); err != nil {
+		return err
+	}
+	path := b.path(lease.Key)
+
+	for {
+		// Linking a complete file creates the lease only if there is none, and never exposes a partial one.
+		tmp, err := b.writeTemp(lease)
+		if err != nil {
+			return err
+		}
+		err = os.Link(tmp, path)
+		os.Remove(tmp)
+		if err == nil {
+			return nil
+		} else if !os.IsExist(err) {
+			return err
+		}
+
+		current, err := readLease(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if current.Owner != lease.Owner && !current.expired(time.Now()) {
+			return &HeldError{Lease: current}
+		}
+
+		// Take the expired lease away. Only one of the runners moves the file, and the one which
+		// moved a lease which another runner has just taken over puts it back.
+		stale := path + "." + lease.Owner
+		if err := os.Rename(path, stale); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		moved, err := readLease(stale)
+		if err == nil && (moved.Owner != current.Owner || !moved.ExpiresAt.Equal(current.ExpiresAt)) {
+			os.Link(stale, path)
+		}
+		os.Remove(stale)
+	}
+}
+
+func (b *fileBackend) Renew(ctx context.Context, lease *Lease) error {
+	path := b.path(lease.Key)
+	current, err := readLease(path)
+	if os.IsNotExist(err) {
+		return ErrLost
+	} else if err != nil {
+		return err
+	}
+	if current.Owner != lease.Owner {
+		return ErrLost
+	}
+
+	tmp, err := b.writeTemp(lease)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (b *fileBackend) Release(ctx context.Context, lease *Lease) error {
+	path := b.path(lease.Key)
+	current, err := readLease(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if current.Owner != lease.Owner {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// writeTemp writes the lease into a new temporary file in the directory and returns its path.
+func (b *fileBackend) writeTemp(lease *Lease) (string, error) {
+	buf, err := json.Marshal(lease)
+	if err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(b.dir, "."+lease.Key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func readLease(path string) (*Lease, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lease := &Lease{}
+	if err := json.Unmarshal(buf, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
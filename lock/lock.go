@@ -0,0 +1,198 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// retryInterval is the interval at which Acquire tries again while another owner holds the lock.
+const retryInterval = 5 * time.Second
+
+// ErrLost is returned when the lease was taken over by another owner, which only happens
+// after the owner failed to renew it before it expired.
+var ErrLost = errors.New("the lease was taken over by another owner")
+
+// ErrExpired is the reason of a lock whose lease expired because it failed to be renewed.
+var ErrExpired = errors.New("the lease expired before it was renewed")
+
+// Lease is a lock of a key held by an owner until it expires.
+type Lease struct {
+	Key        string    `json:"key"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+
+	// version is the version of the lease in the backend, such as the ETag of the object in S3.
+	version string
+}
+
+// expired reports whether the lease has expired at now.
+func (l *Lease) expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// HeldError is returned when another owner holds the lock.
+type HeldError struct {
+	Lease *Lease
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s is locked by %s since %s until %s",
+		e.Lease.Key, e.Lease.Owner, e.Lease.AcquiredAt.Format(time.RFC3339), e.Lease.ExpiresAt.Format(time.RFC3339))
+}
+
+// Backend keeps the leases. Every method gives up when ctx is canceled.
+type Backend interface {
+	// Acquire stores the lease unless another owner holds a lease of the same key which has not expired,
+	// in which case it returns a *HeldError.
+	Acquire(ctx context.Context, lease *Lease) error
+	// Renew stores the lease with its new ExpiresAt, or returns ErrLost if the owner no longer holds it.
+	Renew(ctx context.Context, lease *Lease) error
+	// Release deletes the lease if the owner still holds it.
+	Release(ctx context.Context, lease *Lease) error
+}
+
+// Lock is a lock held by this process. Its lease is renewed until it is released,
+// so that it only expires when the process dies or the backend cannot be reached.
+type Lock struct {
+	backend Backend
+	lease   *Lease
+	ttl     time.Duration
+	stop    chan struct{}
+	done    chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	err    error
+}
+
+// Acquire takes the lock of key with a lease of ttl, waiting up to timeout while another owner holds it.
+// A lease which its owner stopped renewing is taken over once it expires. It stops waiting when ctx is canceled.
+// The context of the lock is derived from ctx, and the work done under the lock should run under it.
+func Acquire(ctx context.Context, backend Backend, key string, ttl, timeout time.Duration) (*Lock, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		now := time.Now()
+		lease := &Lease{Key: key, Owner: owner, AcquiredAt: now, ExpiresAt: now.Add(ttl)}
+		err := backend.Acquire(ctx, lease)
+		if err == nil {
+			l := &Lock{backend: backend, lease: lease, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+			l.ctx, l.cancel = context.WithCancel(ctx)
+			go l.renew()
+			return l, nil
+		}
+
+		remaining := time.Until(deadline)
+		if _, ok := err.(*HeldError); !ok || remaining <= 0 {
+			return nil, err
+		}
+		if remaining > retryInterval {
+			remaining = retryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// Context returns the context which is canceled when the lock is lost or released.
+// Another owner may take the lock over once it is lost, so nothing should be done under it any more.
+func (l *Lock) Context() context.Context {
+	return l.ctx
+}
+
+// Err returns ErrLost or ErrExpired once the lock is lost, and nil while it is held.
+func (l *Lock) Err() error {
+	select {
+	case <-l.done:
+		return l.err
+	default:
+		return nil
+	}
+}
+
+// Release stops renewing the lease and deletes it. It is not canceled with the context of the lock,
+// so that a run stopped by a signal still releases its lock.
+func (l *Lock) Release() error {
+	close(l.stop)
+	<-l.done
+	l.cancel()
+	return l.backend.Release(context.Background(), l.lease)
+}
+
+// renew renews the lease three times in every ttl until the lock is released,
+// and cancels the context of the lock if the lease is taken over or expires.
+func (l *Lock) renew() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	expiry := time.NewTimer(time.Until(l.lease.ExpiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-expiry.C:
+			l.lose(ErrExpired)
+			return
+		case now := <-ticker.C:
+			expiresAt := l.lease.ExpiresAt
+			l.lease.ExpiresAt = now.Add(l.ttl)
+			if err := l.renewLease(); err == ErrLost {
+				l.lose(err)
+				return
+			} else if err != nil {
+				l.lease.ExpiresAt = expiresAt
+				log.Printf("failed to renew the lock of %s: %s\n", l.lease.Key, err)
+				continue
+			}
+			if !expiry.Stop() {
+				<-expiry.C
+			}
+			expiry.Reset(l.ttl)
+		}
+	}
+}
+
+// renewLease stores the lease with its new ExpiresAt. A renewal which does not finish before
+// the next one is due is given up, so that a backend which hangs cannot keep the lease from expiring.
+func (l *Lock) renewLease() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+	defer cancel()
+	return l.backend.Renew(ctx, l.lease)
+}
+
+// lose records why the lock was lost and cancels its context.
+func (l *Lock) lose(err error) {
+	log.Printf("lost the lock of %s: %s\n", l.lease.Key, err)
+	l.err = err
+	l.cancel()
+}
+
+// newOwner returns a name of this process which is unique among the runners.
+func newOwner() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(buf)), nil
+}
@@ -0,0 +1,181 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAcquire_file(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := NewFileBackend(dir)
+	l, err := Acquire(context.Background(), backend, "staging", time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Acquire(context.Background(), backend, "staging", time.Minute, 0)
+	if herr, ok := err.(*HeldError); !ok {
+		t.Fatalf("got %v, want a *HeldError", err)
+	} else if herr.Lease.Owner != l.lease.Owner {
+		t.Errorf("got the owner %s, want %s", herr.Lease.Owner, l.lease.Owner)
+	}
+
+	// The locks of the other configs are independent.
+	other, err := Acquire(context.Background(), backend, "production", time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Acquire(context.Background(), backend, "staging", time.Minute, 0)
+	if err != nil {
+		t.Fatalf("failed to acquire the released lock: %s", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire_stale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A runner which died leaves its lease without renewing it.
+	backend := NewFileBackend(dir)
+	now := time.Now()
+	stale := &Lease{Key: "staging", Owner: "dead", AcquiredAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)}
+	if err := backend.Acquire(context.Background(), stale); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(context.Background(), backend, "staging", time.Minute, 0)
+	if err != nil {
+		t.Fatalf("failed to take over the expired lease: %s", err)
+	}
+	if err := backend.Renew(context.Background(), stale); err != ErrLost {
+		t.Errorf("renewing the expired lease returned %v, want ErrLost", err)
+	}
+	// Releasing the expired lease never deletes the new one.
+	if err := backend.Release(context.Background(), stale); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(context.Background(), backend, "staging", time.Minute, 0); err == nil {
+		t.Error("acquired the lock which is held")
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire_timeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The lease expires while the second runner waits for it.
+	backend := NewFileBackend(dir)
+	now := time.Now()
+	held := &Lease{Key: "staging", Owner: "other", AcquiredAt: now, ExpiresAt: now.Add(100 * time.Millisecond)}
+	if err := backend.Acquire(context.Background(), held); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(context.Background(), backend, "staging", time.Minute, time.Second)
+	if err != nil {
+		t.Fatalf("failed to acquire the lock within the timeout: %s", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire_canceled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := NewFileBackend(dir)
+	now := time.Now()
+	held := &Lease{Key: "staging", Owner: "other", AcquiredAt: now, ExpiresAt: now.Add(time.Minute)}
+	if err := backend.Acquire(context.Background(), held); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if _, err := Acquire(ctx, backend, "staging", time.Minute, time.Minute); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+// lostBackend grants every lease but fails to renew it as if another owner had taken it over.
+type lostBackend struct{}
+
+func (lostBackend) Acquire(ctx context.Context, lease *Lease) error { return nil }
+func (lostBackend) Renew(ctx context.Context, lease *Lease) error   { return ErrLost }
+func (lostBackend) Release(ctx context.Context, lease *Lease) error { return nil }
+
+func TestLock_lost(t *testing.T) {
+	l, err := Acquire(context.Background(), lostBackend{}, "staging", 30*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+
+	select {
+	case <-l.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context of the lock was not canceled after the lease was lost")
+	}
+	if err := l.Err(); err != ErrLost {
+		t.Errorf("got %v, want %v", err, ErrLost)
+	}
+}
+
+// unreachableBackend grants every lease but fails to renew it.
+type unreachableBackend struct{}
+
+func (unreachableBackend) Acquire(ctx context.Context, lease *Lease) error { return nil }
+func (unreachableBackend) Renew(ctx context.Context, lease *Lease) error {
+	return errors.New("connection refused")
+}
+func (unreachableBackend) Release(ctx context.Context, lease *Lease) error { return nil }
+
+func TestLock_expired(t *testing.T) {
+	l, err := Acquire(context.Background(), unreachableBackend{}, "staging", 30*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+
+	select {
+	case <-l.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context of the lock was not canceled after the lease expired")
+	}
+	if err := l.Err(); err != ErrExpired {
+		t.Errorf("got %v, want %v", err, ErrExpired)
+	}
+}
@@ -0,0 +1,97 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/munisystem/rosculus/aws/s3"
+)
+
+type s3Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Backend returns the backend which keeps every lease in an object "<prefix><key>.lock" of the bucket.
+// The leases are created and renewed with the conditional writes of S3.
+func NewS3Backend(client *s3.Client, bucket, prefix string) Backend {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &s3Backend{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (b *s3Backend) key(lease *Lease) string {
+	return b.prefix + lease.Key + ".lock"
+}
+
+func (b *s3Backend) Acquire(ctx context.Context, lease *Lease) error {
+	body, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	key := b.key(lease)
+
+	for {
+		etag, err := b.client.UploadIf(ctx, b.bucket, key, body, "")
+		if err == nil {
+			lease.version = etag
+			return nil
+		} else if err != s3.ErrPreconditionFailed {
+			return err
+		}
+
+		buf, etag, err := b.client.DownloadWithETag(ctx, b.bucket, key)
+		if err == s3.ErrNotExist {
+			continue
+		} else if err != nil {
+			return err
+		}
+		current := &Lease{}
+		if err := json.Unmarshal(buf, current); err != nil {
+			return err
+		}
+		if current.Owner != lease.Owner && !current.expired(time.Now()) {
+			return &HeldError{Lease: current}
+		}
+
+		// Replacing the very object which was read fails if another runner has taken it over meanwhile.
+		etag, err = b.client.UploadIf(ctx, b.bucket, key, body, etag)
+		if err == nil {
+			lease.version = etag
+			return nil
+		} else if err != s3.ErrPreconditionFailed {
+			return err
+		}
+	}
+}
+
+func (b *s3Backend) Renew(ctx context.Context, lease *Lease) error {
+	body, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+
+	etag, err := b.client.UploadIf(ctx, b.bucket, b.key(lease), body, lease.version)
+	if err == s3.ErrPreconditionFailed {
+		return ErrLost
+	} else if err != nil {
+		return err
+	}
+	lease.version = etag
+	return nil
+}
+
+func (b *s3Backend) Release(ctx context.Context, lease *Lease) error {
+	// S3 does not delete objects conditionally, so the object is deleted only if it is still the lease.
+	_, etag, err := b.client.DownloadWithETag(ctx, b.bucket, b.key(lease))
+	if err == s3.ErrNotExist || (err == nil && etag != lease.version) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return b.client.Delete(ctx, b.bucket, b.key(lease))
+}
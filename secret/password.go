@@ -0,0 +1,24 @@
+package secret
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// passwordCharacters are the characters of the generated passwords.
+// They are safe in connection URLs and accepted by every RDS engine.
+const passwordCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~"
+
+// GeneratePassword returns a random password of the length from a cryptographically secure source.
+func GeneratePassword(length int) (string, error) {
+	max := big.NewInt(int64(len(passwordCharacters)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordCharacters[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -0,0 +1,133 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/aws/secretsmanager"
+	"github.com/munisystem/rosculus/aws/ssm"
+)
+
+// Schemes of the references to secrets, which are written as "<scheme>:<path>".
+const (
+	// SchemeSSM refers to a parameter of Parameter Store, e.g. "ssm:/rosculus/password".
+	SchemeSSM = "ssm"
+	// SchemeSecretsManager refers to a secret of Secrets Manager by its name or ARN.
+	// A "#key" suffix picks the key of a secret which holds a JSON object.
+	SchemeSecretsManager = "secretsmanager"
+	// SchemeEnv refers to an environment variable, e.g. "env:DNSIMPLE_TOKEN".
+	SchemeEnv = "env"
+	// SchemeFile refers to a local file, e.g. "file:/run/secrets/password".
+	// A trailing newline is removed.
+	SchemeFile = "file"
+)
+
+// Resolver returns the values of references to secrets. It gives up when ctx is canceled.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Parse splits the reference into its scheme and its path.
+// ok is false if value is not a reference but the secret itself.
+func Parse(value string) (scheme, path string, ok bool) {
+	i := strings.Index(value, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	switch scheme := value[:i]; scheme {
+	case SchemeSSM, SchemeSecretsManager, SchemeEnv, SchemeFile:
+		return scheme, value[i+1:], true
+	default:
+		return "", "", false
+	}
+}
+
+// Resolve returns the secret which value refers to with the resolver,
+// or value itself if it is not a reference.
+func Resolve(ctx context.Context, r Resolver, value string) (string, error) {
+	if _, _, ok := Parse(value); !ok {
+		return value, nil
+	}
+	return r.Resolve(ctx, value)
+}
+
+type resolver struct {
+	ssm            *ssm.Client
+	secretsmanager *secretsmanager.Client
+}
+
+// NewResolver returns the Resolver of every scheme, which reads Parameter Store
+// and Secrets Manager with the session.
+func NewResolver(sess *session.Session) Resolver {
+	return &resolver{
+		ssm:            ssm.New(sess),
+		secretsmanager: secretsmanager.New(sess),
+	}
+}
+
+func (r *resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, path, ok := Parse(ref)
+	if !ok || path == "" {
+		return "", fmt.Errorf("%q is not a reference to a secret", ref)
+	}
+
+	switch scheme {
+	case SchemeSSM:
+		return r.ssm.GetParameter(ctx, path)
+	case SchemeSecretsManager:
+		id, key := path, ""
+		if i := strings.LastIndex(path, "#"); i >= 0 {
+			id, key = path[:i], path[i+1:]
+		}
+		value, err := r.secretsmanager.GetSecretValue(ctx, id)
+		if err != nil || key == "" {
+			return value, err
+		}
+		return jsonKey(value, key)
+	case SchemeEnv:
+		value, ok := os.LookupEnv(path)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", path)
+		}
+		return value, nil
+	default:
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(buf), "\r\n"), nil
+	}
+}
+
+// jsonKey returns the string value of the key of the JSON object.
+func jsonKey(value, key string) (string, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object: %s", err)
+	}
+	v, ok := object[key]
+	if !ok {
+		return "", fmt.Errorf("the secret has no key %s", key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// Static is a Resolver which looks the references up in the map,
+// for tests and local runs without access to the stores.
+type Static map[string]string
+
+func (s Static) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := s[ref]
+	if !ok {
+		return "", fmt.Errorf("secret %s is not found", ref)
+	}
+	return value, nil
+}
@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("ROSCULUS_TEST_TOKEN", "from-env")
+	defer os.Unsetenv("ROSCULUS_TEST_TOKEN")
+
+	// env and file references are resolved without a session.
+	r := &resolver{}
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"plain:text", "plain:text"},
+		{"p@ssw0rd", "p@ssw0rd"},
+		{"env:ROSCULUS_TEST_TOKEN", "from-env"},
+		{"file:" + path, "from-file"},
+	}
+	for _, tc := range cases {
+		got, err := Resolve(context.Background(), r, tc.value)
+		if err != nil {
+			t.Errorf("%s: %s", tc.value, err)
+		} else if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.value, got, tc.want)
+		}
+	}
+
+	for _, value := range []string{"env:ROSCULUS_TEST_UNSET", "file:" + filepath.Join(dir, "missing"), "env:"} {
+		if _, err := Resolve(context.Background(), r, value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestStatic(t *testing.T) {
+	r := Static{"ssm:/rosculus/password": "secret"}
+
+	if got, err := Resolve(context.Background(), r, "ssm:/rosculus/password"); err != nil || got != "secret" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := Resolve(context.Background(), r, "ssm:/rosculus/missing"); err == nil {
+		t.Error("expected an error for a missing reference")
+	}
+}
+
+func TestJSONKey(t *testing.T) {
+	if got, err := jsonKey(`{"password":"secret","port":5432}`, "password"); err != nil || got != "secret" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if got, err := jsonKey(`{"password":"secret","port":5432}`, "port"); err != nil || got != "5432" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := jsonKey(`secret`, "password"); err == nil {
+		t.Error("expected an error for a plain secret")
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		password, err := GeneratePassword(32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 32 {
+			t.Errorf("got a password of %d characters, want 32", len(password))
+		}
+		for _, r := range password {
+			if !strings.ContainsRune(passwordCharacters, r) {
+				t.Errorf("%q has an unexpected character %q", password, r)
+			}
+		}
+		if seen[password] {
+			t.Errorf("%q is generated twice", password)
+		}
+		seen[password] = true
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.json")
+
+	sink, err := NewSink(nil, "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"first", "second"} {
+		if err := sink.Publish(context.Background(), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "second" {
+		t.Errorf("got %q, want %q", buf, "second")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("got permissions %o, want 600", mode)
+	}
+}
+
+func TestParseSink(t *testing.T) {
+	for _, sink := range []string{"secretsmanager:rosculus/staging", "ssm:/rosculus/staging", "s3://bucket/staging.json", "file:/tmp/staging.json"} {
+		if _, _, err := ParseSink(sink); err != nil {
+			t.Errorf("%s: %s", sink, err)
+		}
+	}
+	for _, sink := range []string{"env:PASSWORD", "s3://bucket", "ssm:", "/tmp/staging.json"} {
+		if _, _, err := ParseSink(sink); err == nil {
+			t.Errorf("%s: expected an error", sink)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/munisystem/rosculus/aws/s3"
+	"github.com/munisystem/rosculus/aws/secretsmanager"
+	"github.com/munisystem/rosculus/aws/ssm"
+)
+
+// SchemeS3 is the scheme of the sinks which are S3 objects, e.g. "s3://bucket/key".
+const SchemeS3 = "s3"
+
+// Sink is where a secret is published. Publish gives up when ctx is canceled.
+type Sink interface {
+	Publish(ctx context.Context, value []byte) error
+}
+
+// ParseSink checks the sink, which is one of "secretsmanager:NAME", "ssm:/PATH",
+// "s3://BUCKET/KEY" and "file:/PATH", and returns its scheme and its path.
+func ParseSink(sink string) (scheme, path string, err error) {
+	if strings.HasPrefix(sink, SchemeS3+"://") {
+		u, err := url.Parse(sink)
+		if err != nil {
+			return "", "", err
+		}
+		if u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+			return "", "", fmt.Errorf("%q has no bucket or key", sink)
+		}
+		return SchemeS3, u.Host + u.Path, nil
+	}
+
+	scheme, path, ok := Parse(sink)
+	if !ok || scheme == SchemeEnv {
+		return "", "", fmt.Errorf("%q is not one of secretsmanager:NAME, ssm:/PATH, s3://BUCKET/KEY and file:/PATH", sink)
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("%q has no path", sink)
+	}
+	return scheme, path, nil
+}
+
+// NewSink returns the sink, which writes Secrets Manager, Parameter Store and S3 with the session.
+func NewSink(sess *session.Session, sink string) (Sink, error) {
+	scheme, path, err := ParseSink(sink)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case SchemeSecretsManager:
+		return &secretsManagerSink{client: secretsmanager.New(sess), secretID: path}, nil
+	case SchemeSSM:
+		return &ssmSink{client: ssm.New(sess), name: path}, nil
+	case SchemeS3:
+		i := strings.Index(path, "/")
+		return &s3Sink{client: s3.New(sess), bucket: path[:i], key: path[i+1:]}, nil
+	default:
+		return &fileSink{path: path}, nil
+	}
+}
+
+type secretsManagerSink struct {
+	client   *secretsmanager.Client
+	secretID string
+}
+
+func (s *secretsManagerSink) Publish(ctx context.Context, value []byte) error {
+	return s.client.PutSecretValue(ctx, s.secretID, string(value))
+}
+
+type ssmSink struct {
+	client *ssm.Client
+	name   string
+}
+
+func (s *ssmSink) Publish(ctx context.Context, value []byte) error {
+	return s.client.PutParameter(ctx, s.name, string(value))
+}
+
+type s3Sink struct {
+	client *s3.Client
+	bucket string
+	key    string
+}
+
+func (s *s3Sink) Publish(ctx context.Context, value []byte) error {
+	return s.client.Upload(ctx, s.bucket, s.key, value)
+}
+
+type fileSink struct {
+	path string
+}
+
+// Publish replaces the file with a new one which only the owner can read,
+// so that readers never see a partially written file.
+func (s *fileSink) Publish(ctx context.Context, value []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(s.path), "."+filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// TempFile already creates the file with 0600 permissions, but the sink promises them explicitly.
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
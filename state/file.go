@@ -0,0 +1,75 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore returns the store which keeps every rotation in a file "<dir>/<config>/<id>.json".
+func NewFileStore(dir string) Store {
+	return &fileStore{dir: dir}
+}
+
+// Put replaces the file with a new one, so that readers never see a partially written record.
+func (s *fileStore) Put(ctx context.Context, r *Rotation) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(s.dir, r.Config)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "."+r.ID)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(dir, r.ID+".json"))
+}
+
+func (s *fileStore) List(ctx context.Context, config string) ([]*Rotation, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, config))
+	if os.IsNotExist(err) {
+		return []*Rotation{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rotations := []*Rotation{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(s.dir, config, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		r := &Rotation{}
+		if err := json.Unmarshal(buf, r); err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, r)
+	}
+	sortRotations(rotations)
+
+	return rotations, nil
+}
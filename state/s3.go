@@ -0,0 +1,57 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/munisystem/rosculus/aws/s3"
+)
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store returns the store which keeps every rotation in an object "<prefix><config>/<id>.json" of the bucket.
+func NewS3Store(client *s3.Client, bucket, prefix string) Store {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &s3Store{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (s *s3Store) Put(ctx context.Context, r *Rotation) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.client.Upload(ctx, s.bucket, s.prefix+r.Config+"/"+r.ID+".json", buf)
+}
+
+func (s *s3Store) List(ctx context.Context, config string) ([]*Rotation, error) {
+	keys, err := s.client.List(ctx, s.bucket, s.prefix+config+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := []*Rotation{}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		buf, err := s.client.Download(ctx, s.bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		r := &Rotation{}
+		if err := json.Unmarshal(buf, r); err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, r)
+	}
+	sortRotations(rotations)
+
+	return rotations, nil
+}
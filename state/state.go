@@ -0,0 +1,145 @@
+package state
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Statuses of a rotation.
+const (
+	StatusRunning    = "running"
+	StatusSucceeded  = "succeeded"
+	StatusFailed     = "failed"
+	StatusRolledBack = "rolled-back"
+)
+
+// idLayout is the layout of the time at the start of the IDs, which sort them in the order of their start.
+const idLayout = "20060102T150405.000000000Z"
+
+// Rotation is the record of a rotation of a config.
+type Rotation struct {
+	// ID identifies the rotation among the rotations of the config.
+	ID string `json:"id"`
+	// Config is the name of the config.
+	Config string `json:"config"`
+	// Identifier is the identifier of the clone which the rotation created.
+	Identifier string `json:"identifier"`
+	// Sequence is the generation of the clone.
+	Sequence int `json:"sequence"`
+	// Source describes what the clone was restored from.
+	Source string `json:"source,omitempty"`
+	// SnapshotIdentifier is the snapshot copied from another account for the clone.
+	SnapshotIdentifier string `json:"snapshotIdentifier,omitempty"`
+	Endpoint           string `json:"endpoint,omitempty"`
+	Port               int64  `json:"port,omitempty"`
+	// Record is the DNS record which the rotation pointed at the clone.
+	Record  *Record        `json:"record,omitempty"`
+	Queries []*QueryResult `json:"queries,omitempty"`
+	// Deleted is the previous clones which the rotation deleted.
+	Deleted []string `json:"deleted,omitempty"`
+	// Steps is the steps of the rotation which have completed, in order.
+	Steps      []string   `json:"steps,omitempty"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Record is a DNS record which a rotation changed.
+type Record struct {
+	Domain        string `json:"domain"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	PreviousValue string `json:"previousValue,omitempty"`
+	PreviousTTL   int    `json:"previousTTL,omitempty"`
+}
+
+// QueryResult is the result of a query which a rotation ran on the clone.
+type QueryResult struct {
+	Query string `json:"query"`
+	// RowsAffected is -1 if the database did not tell it.
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// NewRotation returns the running rotation of the config which creates the clone identifier at t.
+func NewRotation(config, identifier string, sequence int, t time.Time) *Rotation {
+	return &Rotation{
+		ID:         t.UTC().Format(idLayout) + "-" + identifier,
+		Config:     config,
+		Identifier: identifier,
+		Sequence:   sequence,
+		Status:     StatusRunning,
+		StartedAt:  t,
+		UpdatedAt:  t,
+	}
+}
+
+// Completed reports whether the step of the rotation has completed.
+func (r *Rotation) Completed(step string) bool {
+	for _, s := range r.Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete marks the step of the rotation as completed.
+func (r *Rotation) Complete(step string) {
+	if !r.Completed(step) {
+		r.Steps = append(r.Steps, step)
+	}
+}
+
+// Undo marks the step of the rotation as not completed, so that it runs again.
+func (r *Rotation) Undo(step string) {
+	steps := r.Steps[:0]
+	for _, s := range r.Steps {
+		if s != step {
+			steps = append(steps, s)
+		}
+	}
+	r.Steps = steps
+}
+
+// Finish marks the rotation as finished at t with the status.
+func (r *Rotation) Finish(status string, t time.Time) {
+	r.Status = status
+	r.UpdatedAt = t
+	r.FinishedAt = &t
+}
+
+// Store keeps the records of the rotations. Every method gives up when ctx is canceled.
+//
+// Every rotation is kept in a record of its own, which only the runner of the rotation writes,
+// so that runners sharing a store never overwrite the records of each other.
+type Store interface {
+	// Put writes the record of the rotation, replacing the previous one of the same rotation.
+	Put(ctx context.Context, r *Rotation) error
+	// List returns the rotations of the config, oldest first.
+	List(ctx context.Context, config string) ([]*Rotation, error)
+}
+
+// Finished reports whether the rotation has finished, so that it cannot be resumed.
+func (r *Rotation) Finished() bool {
+	return r.Status == StatusSucceeded || r.Status == StatusRolledBack
+}
+
+// Find returns the latest rotation which created the clone identifier, or nil if there is none.
+func Find(rotations []*Rotation, identifier string) *Rotation {
+	for i := len(rotations) - 1; i >= 0; i-- {
+		if rotations[i].Identifier == identifier {
+			return rotations[i]
+		}
+	}
+	return nil
+}
+
+func sortRotations(rotations []*Rotation) {
+	sort.Slice(rotations, func(i, j int) bool {
+		return rotations[i].ID < rotations[j].ID
+	})
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rosculus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(dir)
+	if rotations, err := store.List(context.Background(), "staging"); err != nil || len(rotations) != 0 {
+		t.Fatalf("got %v, %v, want no rotation", rotations, err)
+	}
+
+	start := time.Date(2017, 1, 3, 3, 0, 0, 0, time.UTC)
+	second := NewRotation("staging", "db-20170104", 2, start.Add(24*time.Hour))
+	first := NewRotation("staging", "db-20170103", 1, start)
+	other := NewRotation("production", "db-20170103", 1, start)
+	for _, r := range []*Rotation{second, first, other} {
+		if err := store.Put(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Put replaces the record of the same rotation.
+	first.Queries = []*QueryResult{{Query: "DELETE FROM sessions", RowsAffected: 3}}
+	first.Finish(StatusSucceeded, start.Add(time.Hour))
+	if err := store.Put(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+
+	rotations, err := store.List(context.Background(), "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotations) != 2 {
+		t.Fatalf("got %d rotations, want 2", len(rotations))
+	}
+	if !reflect.DeepEqual(rotations[0], first) {
+		t.Errorf("got %+v, want %+v", rotations[0], first)
+	}
+	if rotations[1].ID != second.ID || rotations[1].Status != StatusRunning {
+		t.Errorf("got %+v, want %+v", rotations[1], second)
+	}
+
+	if got := Find(rotations, "db-20170104"); got == nil || got.ID != second.ID {
+		t.Errorf("Find returned %+v, want %s", got, second.ID)
+	}
+	if got := Find(rotations, "db-20170105"); got != nil {
+		t.Errorf("Find returned %+v, want nil", got)
+	}
+}
+
+func TestRotationSteps(t *testing.T) {
+	r := NewRotation("staging", "db-20170103", 1, time.Date(2017, 1, 3, 3, 0, 0, 0, time.UTC))
+	r.Complete("restore")
+	r.Complete("modify")
+	r.Complete("restore")
+	if want := []string{"restore", "modify"}; !reflect.DeepEqual(r.Steps, want) {
+		t.Errorf("got %v, want %v", r.Steps, want)
+	}
+
+	r.Undo("restore")
+	if r.Completed("restore") || !r.Completed("modify") {
+		t.Errorf("got %v, want only modify completed", r.Steps)
+	}
+
+	if r.Finished() {
+		t.Error("the running rotation is finished")
+	}
+	r.Finish(StatusFailed, time.Now())
+	if r.Finished() {
+		t.Error("the failed rotation is finished, want it to be resumable")
+	}
+	r.Finish(StatusSucceeded, time.Now())
+	if !r.Finished() {
+		t.Error("the succeeded rotation is not finished")
+	}
+}